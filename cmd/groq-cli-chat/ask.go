@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"groq-cli-chat/internal/chat"
+	"groq-cli-chat/resources"
+)
+
+func newAskCmd(flags *globalFlags) *cobra.Command {
+	var model string
+
+	cmd := &cobra.Command{
+		Use:   "ask [prompt]",
+		Short: "Send a single prompt and print the response",
+		Long: `Send a single prompt and print only the response to stdout.
+The prompt is taken from the arguments and/or stdin, e.g.:
+
+  git diff | groq-chat ask "write a commit message"
+
+Stats are printed to stderr. Exit codes: 0 success, 1 config error,
+2 missing prompt, 3 API error, 4 authentication error, 5 rate limit
+exceeded, 130 cancelled with Ctrl-C.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			prompt, err := readPrompt(args)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, resources.ErrReadStdin+"\n", err)
+				os.Exit(chat.ExitError)
+			}
+			os.Exit(chat.Ask(flags.loadConfig(), model, prompt))
+		},
+	}
+	cmd.Flags().StringVarP(&model, "model", "m", "", "model to use (default from config)")
+
+	return cmd
+}
+
+// readPrompt joins the prompt arguments with any text piped via stdin
+func readPrompt(args []string) (string, error) {
+	prompt := strings.TrimSpace(strings.Join(args, " "))
+
+	stat, err := os.Stdin.Stat()
+	if err != nil || stat.Mode()&os.ModeCharDevice != 0 {
+		// Stdin is a terminal, nothing is piped
+		return prompt, nil
+	}
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	input := strings.TrimSpace(string(data))
+
+	switch {
+	case input == "":
+		return prompt, nil
+	case prompt == "":
+		return input, nil
+	default:
+		return prompt + "\n\n" + input, nil
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"groq-cli-chat/internal/chat"
+	"groq-cli-chat/internal/history"
+	"groq-cli-chat/resources"
+)
+
+func newHistoryCmd(flags *globalFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Work with the saved chat history",
+	}
+	cmd.AddCommand(newHistorySearchCmd(flags))
+	cmd.AddCommand(newHistoryShowCmd(flags))
+	cmd.AddCommand(newHistoryExportCmd(flags))
+	cmd.AddCommand(newHistoryPruneCmd(flags))
+	cmd.AddCommand(newHistoryRekeyCmd(flags))
+
+	return cmd
+}
+
+func newHistorySearchCmd(flags *globalFlags) *cobra.Command {
+	var filter chat.HistoryFilter
+
+	cmd := &cobra.Command{
+		Use:   "search <text>",
+		Short: "Search prompts and responses in the chat history",
+		Long: `Search prompts and responses in the chat history and print the
+matching entries with a snippet around the first hit, e.g.:
+
+  groq-chat history search --model llama --since 7d "context window"
+  groq-chat history search --regex 'func \w+\('
+
+Text is matched case-insensitively unless --regex is given. Open a result
+with "groq-chat history show <id>" or /history <id> in the chat.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			q, err := filter.Query(strings.Join(args, " "))
+			if err == nil {
+				err = chat.SearchHistory(flags.loadConfig(), q)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, resources.ErrHistoryCmd, err)
+				os.Exit(chat.ExitError)
+			}
+		},
+	}
+	filter.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func newHistoryShowCmd(flags *globalFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <id>",
+		Short: "Print a chat history entry",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := chat.ShowHistory(flags.loadConfig(), args[0]); err != nil {
+				fmt.Fprintf(os.Stderr, resources.ErrHistoryCmd, err)
+				os.Exit(chat.ExitError)
+			}
+		},
+	}
+}
+
+func newHistoryExportCmd(flags *globalFlags) *cobra.Command {
+	var filter chat.HistoryFilter
+	var search, format, output string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export chat history to Markdown, HTML, JSON or fine-tuning JSONL",
+		Long: `Export the selected chat history entries to a single file, e.g.:
+
+  groq-chat history export --since 2025-06-01 -o june.html
+  groq-chat history export --model llama --search docker -o docker.md
+  groq-chat history export --format jsonl -o dataset.jsonl
+
+Formats: markdown (a report with every conversation), html (a self-contained
+page), json (the full records) and jsonl (OpenAI fine-tuning examples, one
+{"messages":[...]} object per line). Without --format it is guessed from the
+output file extension. Without --output the export is written to stdout.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			q, err := filter.Query(search)
+			if err == nil {
+				err = chat.ExportHistory(flags.loadConfig(), q, format, output)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, resources.ErrHistoryCmd, err)
+				os.Exit(chat.ExitError)
+			}
+		},
+	}
+	filter.AddFlags(cmd.Flags())
+	cmd.Flags().StringVarP(&search, "search", "s", "", "only entries whose prompts or responses contain this text")
+	cmd.Flags().StringVarP(&format, "format", "f", "", "export format: "+strings.Join(history.ExportFormats, ", "))
+	cmd.Flags().StringVarP(&output, "output", "o", "", "file to write (default stdout)")
+
+	return cmd
+}
+
+func newHistoryPruneCmd(flags *globalFlags) *cobra.Command {
+	var retention history.Retention
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove old chat history entries",
+		Long: `Remove the oldest chat history entries beyond the given limits, e.g.:
+
+  groq-chat history prune --older-than 30d --dry-run
+  groq-chat history prune --max-entries 1000 --max-size 50MB
+
+Without limits the history_retention settings of the config are used.
+With --dry-run the entries that would be removed are listed and kept.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			policy, err := retention.Policy()
+			if err == nil {
+				err = chat.PruneHistory(flags.loadConfig(), policy, dryRun)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, resources.ErrHistoryCmd, err)
+				os.Exit(chat.ExitError)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&retention.MaxAge, "older-than", "", "remove entries older than this age, e.g. 30d or 12h")
+	cmd.Flags().IntVar(&retention.MaxEntries, "max-entries", 0, "keep at most this many entries")
+	cmd.Flags().StringVar(&retention.MaxSize, "max-size", "", "keep the history below this size, e.g. 50MB")
+	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "only list the entries that would be removed")
+
+	return cmd
+}
+
+func newHistoryRekeyCmd(flags *globalFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rekey",
+		Short: "Change the passphrase of the encrypted chat history",
+		Long: `Re-encrypt the chat history with a new passphrase. The current passphrase
+is taken from the passphrase environment variable or asked for, the new one
+is always asked for twice on the terminal.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := chat.RekeyHistory(flags.loadConfig()); err != nil {
+				fmt.Fprintf(os.Stderr, resources.ErrHistoryCmd, err)
+				os.Exit(chat.ExitError)
+			}
+		},
+	}
+}
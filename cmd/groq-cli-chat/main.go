@@ -11,24 +11,48 @@ import (
 )
 
 func main() {
-	// Initialize configuration
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, resources.ErrLoadConfig, err)
-		os.Exit(1)
-	}
+	flags := &globalFlags{}
 
 	// Initialize root command
 	rootCmd := &cobra.Command{
 		Use:   "groq-cli-chat",
 		Short: "A CLI tool to chat with Groq AI models",
 		Run: func(cmd *cobra.Command, args []string) {
-			chat.Run(cfg)
+			chat.Run(flags.loadConfig())
 		},
 	}
+	rootCmd.PersistentFlags().StringVar(&flags.configPath, "config", "", "path to a config file (default ~/.groq-chat/config.yaml)")
+	rootCmd.PersistentFlags().BoolVar(&flags.incognito, "incognito", false, "don't write history or sessions to disk")
+
+	rootCmd.AddCommand(newAskCmd(flags))
+	rootCmd.AddCommand(newHistoryCmd(flags))
+	rootCmd.AddCommand(newResumeCmd(flags))
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, resources.ErrExecuteCmd, err)
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
+
+// globalFlags holds the flags shared by all commands
+type globalFlags struct {
+	configPath string
+	incognito  bool
+}
+
+// loadConfig loads the config from configPath, or the default config when it is empty
+func (f *globalFlags) loadConfig() *config.Config {
+	var cfg *config.Config
+	var err error
+	if f.configPath != "" {
+		cfg, err = config.LoadSpecificConfig(f.configPath)
+	} else {
+		cfg, err = config.LoadConfig()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, resources.ErrLoadConfig, err)
+		os.Exit(chat.ExitError)
+	}
+	cfg.Incognito = cfg.Incognito || f.incognito
+	return cfg
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+	"groq-cli-chat/internal/chat"
+)
+
+func newResumeCmd(flags *globalFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume [id]",
+		Short: "Continue a saved conversation from the chat history",
+		Long: `Start the chat with a conversation from the history loaded as context,
+with its model, system prompt and parameters restored. New turns are appended
+to the same history entry. Without an ID the most recent entry is resumed.
+Find IDs with "groq-chat history search".`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			id := ""
+			if len(args) > 0 {
+				id = args[0]
+			}
+			chat.Resume(flags.loadConfig(), id)
+		},
+	}
+}
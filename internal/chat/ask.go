@@ -0,0 +1,92 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"groq-cli-chat/internal/config"
+	"groq-cli-chat/internal/groq"
+	"groq-cli-chat/internal/redact"
+	"groq-cli-chat/resources"
+)
+
+// Exit codes returned by Ask
+const (
+	ExitOK       = 0 // Response printed successfully
+	ExitError    = 1 // Configuration or local error
+	ExitUsage    = 2 // Missing prompt or invalid arguments
+	ExitAPIError = 3 // Request to the API failed
+
+	ExitAuthError   = 4 // API key missing, invalid or not permitted
+	ExitRateLimited = 5 // Rate limit still exceeded after retries
+
+	ExitInterrupted = 130 // Cancelled with Ctrl-C, as conventional for SIGINT
+)
+
+// Ask sends a single prompt without entering the REPL.
+// Only the response is written to stdout; stats and errors go to stderr
+// so the output can be piped. It returns the process exit code.
+func Ask(cfg *config.Config, model, prompt string) int {
+	if prompt == "" {
+		fmt.Fprintln(os.Stderr, resources.ErrNoPrompt)
+		return ExitUsage
+	}
+	if model == "" {
+		model = cfg.DefaultModel
+	}
+	if model == "" && len(cfg.Models) > 0 {
+		model = cfg.Models[0]
+	}
+
+	provider, err := newProvider(cfg, os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, resources.ErrCreateClient+"\n", err)
+		return ExitError
+	}
+	redactor, err := redact.New(cfg.Redaction)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, resources.ErrRedaction, err)
+		return ExitError
+	}
+	prompt = redactPrompt(os.Stderr, redactor, prompt)
+
+	// Ctrl-C cancels the request
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	messages := []groq.Message{{Role: groq.RoleUser, Content: prompt}}
+	params := cfg.ParametersFor(model)
+	resp, err := provider.ChatStream(ctx, model, groq.WithSystemPrompt(cfg.SystemPrompt, messages), params, func(delta string) {
+		fmt.Print(delta)
+	})
+	if ctx.Err() != nil {
+		fmt.Println()
+		fmt.Fprint(os.Stderr, resources.InfoRequestCancelled)
+		return ExitInterrupted
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, resources.ErrChat, err)
+		printErrorHint(os.Stderr, err, cfg, askHints)
+		fmt.Fprintln(os.Stderr)
+		return exitCode(err)
+	}
+	fmt.Println() // Finish the streamed response line
+
+	printStats(os.Stderr, resp.Usage)
+
+	if cfg.Incognito {
+		return ExitOK
+	}
+	store, err := openHistory(cfg)
+	if err == nil {
+		applyRetention(store, cfg)
+		_, err = saveChatHistory(store, cfg, redactor, model, cfg.SystemPrompt, messages, resp, params)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, resources.ErrSaveHistory+"\n", err)
+	}
+
+	return ExitOK
+}
@@ -2,7 +2,9 @@ package chat
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,169 +12,403 @@ import (
 
 	"groq-cli-chat/internal/config"
 	"groq-cli-chat/internal/groq"
+	"groq-cli-chat/internal/history"
+	"groq-cli-chat/internal/redact"
+	"groq-cli-chat/internal/session"
 	"groq-cli-chat/resources"
 )
 
+// repl holds the state of an interactive chat
+type repl struct {
+	cfg            *config.Config
+	provider       groq.Provider
+	model          string
+	persona        string           // Name of the selected persona
+	systemPrompt   string           // System prompt of the persona, sent first with every request
+	conversational bool             // Send previous messages with every prompt
+	messages       []groq.Message   // Conversation so far, without the system prompt
+	overrides      groq.Parameters  // Parameters set with /set for this session
+	store          *history.Store   // Nil if the history could not be opened
+	record         *history.Record  // History record that conversation turns are appended to
+	sessions       *session.Store   // Nil if sessions could not be opened
+	current        *session.Session // Session autosaved after every turn, nil until the first save
+	incognito      bool             // Nothing is written to disk: no history, no sessions
+	redactor       *redact.Redactor // Masks secrets in prompts or saved history
+	interrupts     *interruptHandler
+	in             *bufio.Scanner // Input lines, also read by the selection prompts
+	out            io.Writer
+	errOut         io.Writer
+	quit           bool
+}
+
+// Run starts an interactive chat
 func Run(cfg *config.Config) {
-	// Only use the welcome message from resources
-	fmt.Println(resources.WelcomeMessage)
+	r := startREPL(cfg)
+	r.recoverSession()
+	r.loop()
+}
+
+// Resume starts an interactive chat continuing the saved conversation with the
+// given history ID, or the most recent one when id is empty
+func Resume(cfg *config.Config, id string) {
+	r := startREPL(cfg)
+	if err := r.resumeSession(id); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(ExitError)
+	}
+	r.loop()
+}
 
-	client, err := groq.NewClient(cfg.BaseURL, cfg.APIKey)
+// startREPL creates the chat on the terminal, exiting if it cannot be set up
+func startREPL(cfg *config.Config) *repl {
+	provider, err := newProvider(cfg, os.Stderr)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, resources.ErrCreateClient, err)
+		fmt.Fprintf(os.Stderr, resources.ErrCreateClient+"\n", err)
 		os.Exit(1)
 	}
 
+	r, err := newREPL(cfg, provider, os.Stdin, os.Stdout, os.Stderr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	// Ctrl-C cancels the current request instead of killing the program
+	r.interrupts.notify()
+	return r
+}
+
+// newREPL prints the welcome message and prepares the chat state. The chat
+// talks to provider, reads input from in and writes responses to out and
+// errors to errOut.
+func newREPL(cfg *config.Config, provider groq.Provider, in io.Reader, out, errOut io.Writer) (*repl, error) {
+	// Only use the welcome message from resources
+	fmt.Fprintln(out, resources.WelcomeMessage)
+	fmt.Fprintln(out, menu(!cfg.DisableShortcuts))
+	fmt.Fprintln(out)
+
+	interrupts := newInterruptHandler(out)
+	scanner := bufio.NewScanner(interrupts.input(in))
 	currentModel := cfg.DefaultModel
-	
+
 	// Check if default model is empty and prompt user to select one
 	if currentModel == "" {
-		fmt.Println("No default model found in config. Please select a model to use:")
-		newModel, err := selectModel(cfg.Models, "")
+		fmt.Fprintln(out, "No default model found in config. Please select a model to use:")
+		newModel, err := selectModel(scanner, out, cfg.Models, "")
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to select model: %v\n", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("failed to select model: %v", err)
 		}
 		currentModel = newModel
 	}
-	
-	scanner := bufio.NewScanner(os.Stdin)
 
-	for {
-		fmt.Printf(resources.Prompt, currentModel)
-		if !scanner.Scan() {
+	redactor, err := redact.New(cfg.Redaction)
+	if err != nil {
+		return nil, fmt.Errorf(strings.TrimSuffix(resources.ErrRedaction, "\n"), err)
+	}
+
+	r := &repl{
+		cfg:       cfg,
+		provider:  provider,
+		model:     currentModel,
+		incognito: cfg.Incognito,
+		redactor:  redactor,
+		in:        scanner,
+		out:       out,
+		errOut:    errOut,
+		// The default system prompt applies until a persona is selected
+		persona:      resources.DefaultPersonaName,
+		systemPrompt: cfg.SystemPrompt,
+	}
+	r.openStores()
+	if r.store != nil {
+		applyRetention(r.store, cfg)
+	}
+	r.interrupts = interrupts
+	return r, nil
+}
+
+// loop reads and handles input until the user quits
+func (r *repl) loop() {
+	for !r.quit {
+		r.printPrompt()
+		if !r.in.Scan() {
 			break
 		}
-		input := strings.TrimSpace(scanner.Text())
-
-		switch input {
-		case "i":
-			modelInfo, err := client.GetModel(currentModel)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, resources.ErrGetModel, err)
-				fmt.Println() // Add a blank line
-			} else {
-				fmt.Printf(resources.InfoModelDetails,
-					currentModel,
-					modelInfo.OwnedBy,
-					modelInfo.Active,
-					modelInfo.ContextWindow)
-			}
-		
-		case "m":
-			newModel, err := selectModel(cfg.Models, currentModel)
-			if err != nil {
-				fmt.Printf(resources.InfoModelUnchanged, currentModel)
-				fmt.Println() // Add a blank line
-			} else {
-				currentModel = newModel
-			}
-		
-		case "h":
-			if err := ListChatHistory(); err != nil {
-				fmt.Fprintf(os.Stderr, resources.ErrReadHistoryDir, err)
-				fmt.Println() // Add a blank line
-			}
-		
-		case "u":
-			if err := updateModels(cfg, client); err != nil {
-				fmt.Fprintf(os.Stderr, "Failed to update models: %v\n", err)
-				fmt.Println() // Add a blank line
-			}
-		
-		case "c":
-			if err := changeConfig(cfg, client); err != nil {
-				fmt.Fprintf(os.Stderr, "Failed to change configuration: %v\n", err)
-				fmt.Println() // Add a blank line
-			} else {
-				// Update current model to the new default model
-				currentModel = cfg.DefaultModel
-			}
-		case "q":
-			fmt.Println(resources.GoodbyeMessage)
-			return
-		// In the default case of your switch statement
-		default:
-		if input != "" {
-		// Start timing the request
-		startTime := time.Now()
-		
-		resp, err := client.Chat(currentModel, input)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, resources.ErrChat, err)
-			fmt.Println() // Add a blank line after error message
+		input := strings.TrimSpace(r.in.Text())
+		r.interrupts.reset()
+
+		if input == "" || r.dispatch(input) {
 			continue
 		}
-		
-		// Calculate elapsed time if needed
-		elapsedTime := time.Since(startTime).Seconds()
-		if resp.Usage.CompletionTime <= 0 {
-			resp.Usage.CompletionTime = elapsedTime
-		}
-		
-		// Display the response content
-		fmt.Println(resp.Choices[0].Message.Content)
-		
-		// Calculate tokens per second (avoid division by zero)
-		tokensPerSecond := 0.0
-		if resp.Usage.CompletionTime > 0 {
-			tokensPerSecond = float64(resp.Usage.TotalTokens) / resp.Usage.CompletionTime
+
+		// A leading backslash sends the rest of the line as-is, e.g. \q or \/help
+		r.send(strings.TrimPrefix(input, `\`))
+	}
+	if r.interrupts.quitting() {
+		fmt.Fprintln(r.out, resources.GoodbyeMessage)
+	}
+	r.close()
+}
+
+// openStores opens the history and the session store. Opening the history may
+// migrate or encrypt it, so incognito chats leave it closed until incognito is
+// turned off; their sessions can be listed and loaded but are never written.
+func (r *repl) openStores() {
+	r.store = nil
+	if !r.incognito {
+		store, err := openHistory(r.cfg)
+		if err != nil {
+			fmt.Fprintln(r.errOut, err)
 		}
-		
-		// Display statistics
-		fmt.Printf(resources.StatsFormat,
-			resp.Usage.TotalTokens,
-			resp.Usage.CompletionTime,
-			tokensPerSecond)
-		fmt.Println() // Add a blank line after stats
-		
-		if err := saveChatHistory(input, resp, currentModel); err != nil {
-			fmt.Fprintf(os.Stderr, resources.ErrSaveHistory, err)
-			fmt.Println() // Add a blank line
+		r.store = store
+	}
+
+	sessions, err := openSessions(r.cfg, r.store, r.incognito)
+	if err != nil {
+		fmt.Fprintln(r.errOut, err)
+	}
+	r.sessions = sessions
+}
+
+// close marks a clean exit, so the next start does not offer to restore the session
+func (r *repl) close() {
+	if r.sessions == nil || r.incognito {
+		return
+	}
+	if err := r.sessions.ClearActive(); err != nil {
+		fmt.Fprintln(r.errOut, err)
+	}
+}
+
+// printPrompt shows the input prompt with the current model
+func (r *repl) printPrompt() {
+	marker := ""
+	if r.incognito {
+		marker = resources.IncognitoMarker
+	}
+	if r.conversational {
+		fmt.Fprintf(r.out, resources.ConversationPrompt, marker, r.model, len(r.messages)/2+1)
+	} else {
+		fmt.Fprintf(r.out, resources.Prompt, marker, r.model)
+	}
+}
+
+// send sends a prompt to the current model and prints the streamed response
+func (r *repl) send(input string) {
+	// Start timing the request
+	startTime := time.Now()
+
+	input = redactPrompt(r.errOut, r.redactor, input)
+
+	// In one-shot mode only the current prompt is sent
+	request := []groq.Message{{Role: groq.RoleUser, Content: input}}
+	if r.conversational {
+		request = append(r.messages, request...)
+	}
+
+	// Print tokens as they arrive
+	ctx, done := r.interrupts.requestContext()
+	params := r.cfg.ParametersFor(r.model).Merge(r.overrides)
+	resp, err := r.provider.ChatStream(ctx, r.model, groq.WithSystemPrompt(r.systemPrompt, request), params, func(delta string) {
+		fmt.Fprint(r.out, delta)
+	})
+	cancelled := ctx.Err() == context.Canceled
+	done()
+	if cancelled {
+		fmt.Fprintln(r.out)
+		fmt.Fprint(r.out, resources.InfoRequestCancelled)
+		fmt.Fprintln(r.out) // Add a blank line
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(r.errOut, resources.ErrChat, err)
+		printErrorHint(r.errOut, err, r.cfg, replHints)
+		fmt.Fprintln(r.out) // Add a blank line after error message
+		return
+	}
+	fmt.Fprintln(r.out) // Finish the streamed response line
+
+	// Keep the exchange so follow-up prompts have the full context
+	if r.conversational {
+		r.messages = append(request, resp.Choices[0].Message)
+	}
+
+	// Calculate elapsed time if needed
+	elapsedTime := time.Since(startTime).Seconds()
+	if resp.Usage.CompletionTime <= 0 {
+		resp.Usage.CompletionTime = elapsedTime
+	}
+
+	// Display statistics
+	printStats(r.out, resp.Usage)
+	fmt.Fprintln(r.out) // Add a blank line after stats
+
+	r.autosave()
+
+	if r.incognito {
+		return
+	}
+	// The history failed to open, e.g. for a wrong passphrase; say so on every turn
+	if r.store == nil {
+		fmt.Fprint(r.errOut, resources.WarnHistoryNotSaved)
+		fmt.Fprintln(r.out) // Add a blank line
+		return
+	}
+	// Later turns of a conversation are appended to the record of its first turn
+	if r.conversational && r.record != nil {
+		err = appendChatHistory(r.store, r.redactor, r.record, r.model, r.systemPrompt, request, resp, params)
+	} else {
+		var rec *history.Record
+		rec, err = saveChatHistory(r.store, r.cfg, r.redactor, r.model, r.systemPrompt, request, resp, params)
+		if r.conversational {
+			r.record = rec
 		}
+	}
+	if err != nil {
+		fmt.Fprintf(r.errOut, resources.ErrSaveHistory, err)
+		fmt.Fprintln(r.out) // Add a blank line
+	}
+}
+
+// setParameter handles "/set <name> <value>"; without arguments it shows the
+// parameters in effect, i.e. base merged with the session overrides
+func setParameter(out io.Writer, overrides *groq.Parameters, args string, base groq.Parameters) error {
+	name, value, _ := strings.Cut(args, " ")
+	if name != "" {
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf(resources.UsageSet)
 		}
+		if err := overrides.Set(name, value); err != nil {
+			return err
 		}
 	}
+	fmt.Fprintf(out, resources.InfoParameters, describeParameters(base.Merge(*overrides)))
+	return nil
+}
+
+// describeParameters formats params for display, noting when none are set
+func describeParameters(params groq.Parameters) string {
+	if text := params.String(); text != "" {
+		return text
+	}
+	return resources.DefaultParameters
 }
 
-func selectModel(models []string, currentModel string) (string, error) {
+// newProvider creates the provider of the configured type, with its retry policy
+// and timeout. Notes about retried requests are written to errOut.
+func newProvider(cfg *config.Config, errOut io.Writer) (groq.Provider, error) {
+	return groq.NewProvider(groq.Options{
+		Type:    cfg.ProviderType,
+		BaseURL: cfg.BaseURL,
+		APIKey:  cfg.APIKey,
+		Auth:    cfg.Auth,
+		Headers: cfg.Headers,
+		Query:   cfg.Query,
+		Retry:   cfg.Retry,
+		Timeout: cfg.Timeout,
+		OnRetry: func(attempt, maxAttempts int, delay time.Duration, statusCode int) {
+			fmt.Fprintf(errOut, resources.InfoRetrying, statusCode, delay.Seconds(), attempt+1, maxAttempts)
+		},
+	})
+}
+
+// printStats writes the usage statistics line of a response to w
+func printStats(w io.Writer, usage groq.Usage) {
+	fmt.Fprintf(w, resources.StatsFormat,
+		usage.TotalTokens,
+		usage.PromptTokens,
+		usage.OutputTokens(),
+		usage.QueueTime,
+		usage.CompletionTime,
+		usage.OutputTokensPerSecond())
+}
+
+func selectModel(in *bufio.Scanner, out io.Writer, models []string, currentModel string) (string, error) {
 	// Limit to 20 models for selection
 	displayModels := models
 	if len(displayModels) > 20 {
 		displayModels = displayModels[:20]
 	}
 
-	fmt.Println(resources.SelectModelHeader)
+	fmt.Fprintln(out, resources.SelectModelHeader)
 	for i, model := range displayModels {
-		fmt.Printf("%d - %s\n", i, model)
+		fmt.Fprintf(out, "%d - %s\n", i, model)
 	}
-	
+
 	// Keep prompting until valid selection or explicit cancel
 	for {
-		fmt.Printf(resources.SelectModelPrompt, len(displayModels)-1)
+		fmt.Fprintf(out, resources.SelectModelPrompt, len(displayModels)-1)
 
-		scanner := bufio.NewScanner(os.Stdin)
-		if !scanner.Scan() {
+		if !in.Scan() {
 			return "", fmt.Errorf(resources.ErrReadInput)
 		}
-		choice := strings.TrimSpace(scanner.Text())
-		
+		choice := strings.TrimSpace(in.Text())
+
 		// Allow user to cancel selection
 		if choice == "" || strings.ToLower(choice) == "q" || strings.ToLower(choice) == "quit" {
 			return "", fmt.Errorf("model selection cancelled")
 		}
-		
+
 		index, err := parseChoice(choice, len(displayModels))
 		if err != nil {
 			// Show error but allow retry
-			fmt.Printf("Invalid selection: %v. Please try again or press Enter/Q to cancel.\n", err)
+			fmt.Fprintf(out, "Invalid selection: %v. Please try again or press Enter/Q to cancel.\n", err)
 			continue
 		}
-		
+
 		return displayModels[index], nil
 	}
 }
 
+// selectPersona prompts the user to pick a persona from the config.
+// It returns an empty name when the default system prompt is chosen.
+func selectPersona(in *bufio.Scanner, out io.Writer, cfg *config.Config) (string, error) {
+	names := append([]string{""}, cfg.PersonaNames()...)
+
+	fmt.Fprintln(out, resources.SelectPersonaHeader)
+	for i, name := range names {
+		_, prompt, _ := cfg.Persona(name)
+		if name == "" {
+			name = resources.DefaultPersonaName
+		}
+		if prompt == "" {
+			prompt = resources.NoSystemPrompt
+		}
+		fmt.Fprintf(out, "%d - %s: %s\n", i, name, truncate(prompt, 60))
+	}
+
+	for {
+		fmt.Fprintf(out, resources.SelectPersonaPrompt, len(names)-1)
+
+		if !in.Scan() {
+			return "", fmt.Errorf(resources.ErrReadInput)
+		}
+		choice := strings.TrimSpace(in.Text())
+
+		// Allow user to cancel selection
+		if choice == "" || strings.ToLower(choice) == "q" || strings.ToLower(choice) == "quit" {
+			return "", fmt.Errorf("persona selection cancelled")
+		}
+
+		index, err := parseChoice(choice, len(names))
+		if err != nil {
+			fmt.Fprintf(out, "Invalid selection: %v. Please try again or press Enter/Q to cancel.\n", err)
+			continue
+		}
+
+		return names[index], nil
+	}
+}
+
+// truncate shortens a single-line preview of s to at most max runes
+func truncate(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
+
 func parseChoice(choice string, max int) (int, error) {
 	var index int
 	if _, err := fmt.Sscanf(choice, "%d", &index); err != nil {
@@ -184,36 +420,15 @@ func parseChoice(choice string, max int) (int, error) {
 	return index, nil
 }
 
-func saveChatHistory(input string, resp *groq.ChatResponse, model string) error {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf(resources.ErrHomeDir, err)
-	}
-
-	historyDir := filepath.Join(homeDir, ".groq-chat", "history")
-	if err := os.MkdirAll(historyDir, 0755); err != nil {
-		return fmt.Errorf(resources.ErrCreateHistoryDir, err)
-	}
+func updateModels(ctx context.Context, in *bufio.Scanner, out io.Writer, cfg *config.Config, provider groq.Provider) error {
+	fmt.Fprintf(out, "Fetching latest models from %s API...\n", cfg.ProviderName)
 
-	timestamp := time.Now().Format("20060102_150405")
-	filename := filepath.Join(historyDir, fmt.Sprintf("chat_%s.md", timestamp))
-	content := fmt.Sprintf(resources.HistoryFormat,
-		timestamp, model, input, resp.Choices[0].Message.Content,
-		resp.Usage.TotalTokens, resp.Usage.CompletionTime,
-		float64(resp.Usage.TotalTokens)/resp.Usage.CompletionTime)
-
-	return os.WriteFile(filename, []byte(content), 0644)
-}
-
-func updateModels(cfg *config.Config, client *groq.Client) error {
-	fmt.Printf("Fetching latest models from %s API...\n", cfg.ProviderName)
-	
-	// Use the existing client instead of creating a new one with a modified URL
-	newModels, err := client.ListModels()
+	// Use the existing provider instead of creating a new one with a modified URL
+	newModels, err := provider.ListModels(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to fetch models: %v", err)
 	}
-	
+
 	// Filter out excluded models
 	var filteredNewModels []string
 	for _, model := range newModels {
@@ -228,16 +443,16 @@ func updateModels(cfg *config.Config, client *groq.Client) error {
 			filteredNewModels = append(filteredNewModels, model)
 		}
 	}
-	
+
 	// Compare old and new lists
 	oldModels := cfg.Models
-	
+
 	// Check if lists are identical
 	if areModelListsIdentical(oldModels, filteredNewModels) {
-		fmt.Println("No updates available. Your model list is already up to date.")
+		fmt.Fprintln(out, "No updates available. Your model list is already up to date.")
 		return nil
 	}
-	
+
 	// Find new models (in new list but not in old list)
 	var addedModels []string
 	for _, newModel := range filteredNewModels {
@@ -245,7 +460,7 @@ func updateModels(cfg *config.Config, client *groq.Client) error {
 			addedModels = append(addedModels, newModel)
 		}
 	}
-	
+
 	// Find removed models (in old list but not in new list)
 	var removedModels []string
 	for _, oldModel := range oldModels {
@@ -253,60 +468,59 @@ func updateModels(cfg *config.Config, client *groq.Client) error {
 			removedModels = append(removedModels, oldModel)
 		}
 	}
-	
+
 	// Display changes
-	fmt.Println("────────┤ Model Updates Available ├─────────")
-	
+	fmt.Fprintln(out, "────────┤ Model Updates Available ├─────────")
+
 	if len(addedModels) > 0 {
-		fmt.Println("New models:")
+		fmt.Fprintln(out, "New models:")
 		for _, model := range addedModels {
-			fmt.Printf("  + %s\n", model)
+			fmt.Fprintf(out, "  + %s\n", model)
 		}
 	}
-	
+
 	if len(removedModels) > 0 {
-		fmt.Println("Removed models:")
+		fmt.Fprintln(out, "Removed models:")
 		for _, model := range removedModels {
-			fmt.Printf("  - %s\n", model)
+			fmt.Fprintf(out, "  - %s\n", model)
 		}
 	}
-	
-	fmt.Printf("Old list: %d models | New list: %d models\n", len(oldModels), len(filteredNewModels))
-	fmt.Println("─────────────────────────────────────")
-	
+
+	fmt.Fprintf(out, "Old list: %d models | New list: %d models\n", len(oldModels), len(filteredNewModels))
+	fmt.Fprintln(out, "─────────────────────────────────────")
+
 	// Ask user if they want to update
-	fmt.Print("Do you want to update the models list? (y/n): ")
-	scanner := bufio.NewScanner(os.Stdin)
-	if !scanner.Scan() {
+	fmt.Fprint(out, "Do you want to update the models list? (y/n): ")
+	if !in.Scan() {
 		return fmt.Errorf("failed to read input")
 	}
-	
-	response := strings.ToLower(strings.TrimSpace(scanner.Text()))
+
+	response := strings.ToLower(strings.TrimSpace(in.Text()))
 	if response == "y" || response == "yes" {
 		// Update config
 		// No need to construct a new path, use the one from the config
 		configPath := cfg.ConfigPath
-		
+
 		// Update the config struct
 		cfg.Models = filteredNewModels
-		
+
 		// Check if default model is still valid
 		if !contains(filteredNewModels, cfg.DefaultModel) && len(filteredNewModels) > 0 {
-			fmt.Printf("Warning: Your default model '%s' is no longer available. Setting default to '%s'.\n", 
+			fmt.Fprintf(out, "Warning: Your default model '%s' is no longer available. Setting default to '%s'.\n",
 				cfg.DefaultModel, filteredNewModels[0])
 			cfg.DefaultModel = filteredNewModels[0]
 		}
-		
+
 		// Save the updated config
 		if err := config.SaveConfig(cfg, configPath); err != nil {
 			return fmt.Errorf("failed to save updated config: %v", err)
 		}
-		
-		fmt.Println("Models list updated successfully!")
+
+		fmt.Fprintln(out, "Models list updated successfully!")
 	} else {
-		fmt.Println("Update cancelled. Models list remains unchanged.")
+		fmt.Fprintln(out, "Update cancelled. Models list remains unchanged.")
 	}
-	
+
 	return nil
 }
 
@@ -315,20 +529,20 @@ func areModelListsIdentical(list1, list2 []string) bool {
 	if len(list1) != len(list2) {
 		return false
 	}
-	
+
 	// Create maps for faster lookup
 	map1 := make(map[string]bool)
 	for _, item := range list1 {
 		map1[item] = true
 	}
-	
+
 	// Check if all items in list2 are in list1
 	for _, item := range list2 {
 		if !map1[item] {
 			return false
 		}
 	}
-	
+
 	return true
 }
 
@@ -342,113 +556,109 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
-// changeConfig allows the user to select a different configuration file
-func changeConfig(cfg *config.Config, client *groq.Client) error {
+// changeConfig allows the user to select a different configuration file.
+// It returns the provider for the new configuration.
+func changeConfig(in *bufio.Scanner, out, errOut io.Writer, cfg *config.Config) (groq.Provider, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return fmt.Errorf(resources.ErrHomeDir, err)
+		return nil, fmt.Errorf(resources.ErrHomeDir, err)
 	}
 
 	configDir := filepath.Join(homeDir, ".groq-chat")
-	
+
 	// List all YAML files in the config directory
 	files, err := os.ReadDir(configDir)
 	if err != nil {
-		return fmt.Errorf("failed to read config directory: %v", err)
+		return nil, fmt.Errorf("failed to read config directory: %v", err)
 	}
-	
+
 	var yamlFiles []string
 	for _, file := range files {
 		if !file.IsDir() && (strings.HasSuffix(file.Name(), ".yaml") || strings.HasSuffix(file.Name(), ".yml")) {
 			yamlFiles = append(yamlFiles, file.Name())
 		}
 	}
-	
+
 	if len(yamlFiles) == 0 {
-		return fmt.Errorf("no configuration files found in %s", configDir)
+		return nil, fmt.Errorf("no configuration files found in %s", configDir)
 	}
-	
+
 	// Display available configuration files
-	fmt.Println("────────┤ Available Configurations ├─────────")
+	fmt.Fprintln(out, "────────┤ Available Configurations ├─────────")
 	for i, file := range yamlFiles {
-		fmt.Printf("%d - %s\n", i, file)
+		fmt.Fprintf(out, "%d - %s\n", i, file)
 	}
-	
+
 	// Prompt user to select a configuration
 	var selectedConfig string
 	for {
-		fmt.Printf("─────────────────────────────────────\nSelect configuration (0-%d): ", len(yamlFiles)-1)
-		
-		scanner := bufio.NewScanner(os.Stdin)
-		if !scanner.Scan() {
-			return fmt.Errorf(resources.ErrReadInput)
-		}
-		choice := strings.TrimSpace(scanner.Text())
-		
+		fmt.Fprintf(out, "─────────────────────────────────────\nSelect configuration (0-%d): ", len(yamlFiles)-1)
+
+		if !in.Scan() {
+			return nil, fmt.Errorf(resources.ErrReadInput)
+		}
+		choice := strings.TrimSpace(in.Text())
+
 		// Allow user to cancel selection
 		if choice == "" || strings.ToLower(choice) == "q" || strings.ToLower(choice) == "quit" {
-			return fmt.Errorf("configuration selection cancelled")
+			return nil, fmt.Errorf("configuration selection cancelled")
 		}
-		
+
 		index, err := parseChoice(choice, len(yamlFiles))
 		if err != nil {
-			fmt.Printf("Invalid selection: %v. Please try again or press Enter/Q to cancel.\n", err)
+			fmt.Fprintf(out, "Invalid selection: %v. Please try again or press Enter/Q to cancel.\n", err)
 			continue
 		}
-		
+
 		selectedConfig = yamlFiles[index]
 		break
 	}
-	
+
 	// Load and validate the selected configuration
 	newCfg, err := config.LoadSpecificConfig(filepath.Join(configDir, selectedConfig))
 	if err != nil {
-		return fmt.Errorf("failed to load selected configuration: %v", err)
+		return nil, fmt.Errorf("failed to load selected configuration: %v", err)
 	}
-	
+
 	// Validate the new configuration
 	if err := validateConfig(newCfg); err != nil {
-		return fmt.Errorf("invalid configuration: %v", err)
+		return nil, fmt.Errorf("invalid configuration: %v", err)
 	}
-	
+
 	// Ask for confirmation
-	fmt.Printf("New configuration loaded from %s\n", selectedConfig)
-	fmt.Printf("Base URL: %s\n", newCfg.BaseURL)
-	fmt.Printf("Default Model: %s\n", newCfg.DefaultModel)
-	fmt.Printf("Available Models: %d\n", len(newCfg.Models))
-	
-	fmt.Print("Do you want to apply this configuration? (y/n): ")
-	scanner := bufio.NewScanner(os.Stdin)
-	if !scanner.Scan() {
-		return fmt.Errorf(resources.ErrReadInput)
-	}
-	
-	response := strings.ToLower(strings.TrimSpace(scanner.Text()))
+	fmt.Fprintf(out, "New configuration loaded from %s\n", selectedConfig)
+	fmt.Fprintf(out, "Base URL: %s\n", newCfg.BaseURL)
+	fmt.Fprintf(out, "Default Model: %s\n", newCfg.DefaultModel)
+	fmt.Fprintf(out, "Available Models: %d\n", len(newCfg.Models))
+
+	fmt.Fprint(out, "Do you want to apply this configuration? (y/n): ")
+	if !in.Scan() {
+		return nil, fmt.Errorf(resources.ErrReadInput)
+	}
+
+	response := strings.ToLower(strings.TrimSpace(in.Text()))
 	if response != "y" && response != "yes" {
-		return fmt.Errorf("configuration change cancelled")
+		return nil, fmt.Errorf("configuration change cancelled")
 	}
-	
-	// Apply the new configuration
-	*cfg = *newCfg
-	
-	// Create a new client with the updated configuration
-	newClient, err := groq.NewClient(cfg.BaseURL, cfg.APIKey)
+
+	// Create a provider for the new configuration before applying it
+	provider, err := newProvider(newCfg, errOut)
 	if err != nil {
-		return fmt.Errorf("failed to create client with new configuration: %v", err)
+		return nil, fmt.Errorf("failed to create client with new configuration: %v", err)
 	}
-	
-	// Update the client reference
-	*client = *newClient
-	
+
+	// Apply the new configuration
+	*cfg = *newCfg
+
 	// Display success message with config file name
-	fmt.Printf("Configuration updated successfully from '%s'!\n", selectedConfig)
-	
+	fmt.Fprintf(out, "Configuration updated successfully from '%s'!\n", selectedConfig)
+
 	// Display the app title from the new configuration
-	fmt.Println("\n" + cfg.AppTitle)
-	fmt.Println(resources.MenuOptions)
-	fmt.Println() // Add a blank line after menu options
-	
-	return nil
+	fmt.Fprintln(out, "\n"+cfg.AppTitle)
+	fmt.Fprintln(out, menu(!cfg.DisableShortcuts))
+	fmt.Fprintln(out) // Add a blank line after menu options
+
+	return provider, nil
 }
 
 // validateConfig checks if the configuration has all required fields
@@ -457,11 +667,11 @@ func validateConfig(cfg *config.Config) error {
 	if cfg.BaseURL == "" {
 		return fmt.Errorf("base_url is missing")
 	}
-	
+
 	if len(cfg.Models) == 0 {
 		return fmt.Errorf("no models defined")
 	}
-	
+
 	// If default model is specified, check if it exists in the models list
 	if cfg.DefaultModel != "" {
 		found := false
@@ -471,7 +681,7 @@ func validateConfig(cfg *config.Config) error {
 				break
 			}
 		}
-		
+
 		if !found {
 			return fmt.Errorf("default model '%s' not found in models list", cfg.DefaultModel)
 		}
@@ -479,15 +689,19 @@ func validateConfig(cfg *config.Config) error {
 		// If no default model is specified, set it to the first model
 		cfg.DefaultModel = cfg.Models[0]
 	}
-	
+
+	if _, err := redact.New(cfg.Redaction); err != nil {
+		return fmt.Errorf(strings.TrimSuffix(resources.ErrRedaction, "\n"), err)
+	}
+
 	// Check if API key is set
-	if cfg.APIKey == "" {
+	if cfg.APIKey == "" && cfg.RequiresAPIKey() {
 		// Try to get it from environment
 		cfg.APIKey = os.Getenv(cfg.APIKeyName)
 		if cfg.APIKey == "" {
 			return fmt.Errorf("API key not found in environment variable %s", cfg.APIKeyName)
 		}
 	}
-	
+
 	return nil
 }
@@ -0,0 +1,332 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+
+	"groq-cli-chat/internal/config"
+	"groq-cli-chat/internal/redact"
+	"groq-cli-chat/resources"
+)
+
+// command is a REPL command invoked as /name [args] or /alias [args].
+// The letters of the commands that existed before slash commands also work
+// without the slash unless shortcuts are disabled.
+type command struct {
+	name     string
+	aliases  []string
+	args     string // Argument synopsis shown in /help
+	help     string
+	shortcut string // Input that runs the command without the slash, if any
+	menu     string // Label in the menu showing the shortcut
+	run      func(r *repl, args string)
+}
+
+// commands is the table of REPL commands in the order shown by /help
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "info", aliases: []string{"i"}, help: resources.HelpInfo, shortcut: "i", menu: resources.MenuInfo, run: (*repl).info},
+		{name: "model", aliases: []string{"m"}, args: "[name]", help: resources.HelpModel, shortcut: "m", menu: resources.MenuModel, run: (*repl).selectModel},
+		{name: "update", aliases: []string{"u"}, help: resources.HelpUpdate, shortcut: "u", menu: resources.MenuUpdate, run: (*repl).updateModels},
+		{name: "history", aliases: []string{"h"}, args: "[number|id]", help: resources.HelpHistory, shortcut: "h", menu: resources.MenuHistory, run: (*repl).history},
+		{name: "session", args: "[command] [name]", help: resources.HelpSession, run: (*repl).sessionCommand},
+		{name: "resume", args: "[id]", help: resources.HelpResume, run: (*repl).resume},
+		{name: "search", args: "[flags] <text>", help: resources.HelpSearch, run: (*repl).search},
+		{name: "config", aliases: []string{"c"}, help: resources.HelpConfig, shortcut: "c", menu: resources.MenuConfig, run: (*repl).changeConfig},
+		{name: "persona", aliases: []string{"p"}, args: "[name]", help: resources.HelpPersona, run: (*repl).selectPersona},
+		{name: "new", aliases: []string{"n"}, help: resources.HelpNew, run: (*repl).newConversation},
+		{name: "mode", aliases: []string{"t"}, args: "[oneshot|conversation]", help: resources.HelpMode, run: (*repl).setMode},
+		{name: "incognito", args: "[on|off]", help: resources.HelpIncognito, run: (*repl).setIncognito},
+		{name: "set", args: "[name value]", help: resources.HelpSet, run: (*repl).set},
+		{name: "help", aliases: []string{"?"}, help: resources.HelpHelp, run: (*repl).help},
+		{name: "quit", aliases: []string{"q", "exit"}, help: resources.HelpQuit, shortcut: "q", menu: resources.MenuQuit, run: (*repl).exit},
+	}
+}
+
+// menu returns the one-line list of commands shown at startup. Shortcut labels
+// are only used while shortcuts are enabled.
+func menu(shortcuts bool) string {
+	labels := make([]string, len(commands))
+	for i, cmd := range commands {
+		labels[i] = "/" + cmd.name
+		if shortcuts && cmd.menu != "" {
+			labels[i] = cmd.menu
+		}
+	}
+	return strings.Join(labels, resources.MenuSeparator)
+}
+
+// findShortcut looks up a command by its shortcut, matching it exactly so
+// that e.g. "Q" is sent as a prompt
+func findShortcut(input string) *command {
+	for i := range commands {
+		if commands[i].shortcut == input {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// findCommand looks up a command by name or alias
+func findCommand(name string) *command {
+	name = strings.ToLower(name)
+	for i := range commands {
+		if commands[i].name == name || contains(commands[i].aliases, name) {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// dispatch runs input as a command and reports whether it was one.
+// Unknown /commands are reported as errors rather than sent to the model.
+func (r *repl) dispatch(input string) bool {
+	if !strings.HasPrefix(input, "/") {
+		if r.cfg.DisableShortcuts {
+			return false
+		}
+		// Shortcut letter, e.g. q; any other text is a prompt
+		cmd := findShortcut(input)
+		if cmd == nil {
+			return false
+		}
+		cmd.run(r, "")
+		return true
+	}
+
+	name, args, _ := strings.Cut(strings.TrimPrefix(input, "/"), " ")
+	cmd := findCommand(name)
+	if cmd == nil {
+		fmt.Fprintf(r.errOut, resources.ErrUnknownCommand+"\n", name)
+		fmt.Fprintln(r.out) // Add a blank line
+		return true
+	}
+	cmd.run(r, strings.TrimSpace(args))
+	return true
+}
+
+func (r *repl) info(string) {
+	ctx, done := r.interrupts.requestContext()
+	modelInfo, err := r.provider.GetModel(ctx, r.model)
+	done()
+	if err != nil {
+		fmt.Fprintf(r.errOut, resources.ErrGetModel, err)
+		printErrorHint(r.errOut, err, r.cfg, replHints)
+		fmt.Fprintln(r.out) // Add a blank line
+		return
+	}
+	fmt.Fprintf(r.out, resources.InfoModelDetails,
+		r.model,
+		modelInfo.OwnedBy,
+		modelInfo.Active,
+		modelInfo.ContextWindow)
+}
+
+func (r *repl) selectModel(args string) {
+	if args != "" {
+		if !config.IsValidModel(args, r.cfg.Models) {
+			fmt.Fprintf(r.errOut, resources.ErrUnknownModel+"\n", args)
+			fmt.Fprintln(r.out) // Add a blank line
+			return
+		}
+		r.model = args
+		return
+	}
+
+	newModel, err := selectModel(r.in, r.out, r.cfg.Models, r.model)
+	if err != nil {
+		fmt.Fprintf(r.out, resources.InfoModelUnchanged, r.model)
+		fmt.Fprintln(r.out) // Add a blank line
+		return
+	}
+	r.model = newModel
+}
+
+func (r *repl) updateModels(string) {
+	ctx, done := r.interrupts.requestContext()
+	err := updateModels(ctx, r.in, r.out, r.cfg, r.provider)
+	done()
+	if err != nil {
+		fmt.Fprintf(r.errOut, "Failed to update models: %v\n", err)
+		fmt.Fprintln(r.out) // Add a blank line
+	}
+}
+
+func (r *repl) changeConfig(string) {
+	provider, err := changeConfig(r.in, r.out, r.errOut, r.cfg)
+	if err != nil {
+		fmt.Fprintf(r.errOut, "Failed to change configuration: %v\n", err)
+		fmt.Fprintln(r.out) // Add a blank line
+		return
+	}
+	r.provider = provider
+	// Secrets are detected with the settings of the new config
+	redactor, err := redact.New(r.cfg.Redaction)
+	if err != nil {
+		fmt.Fprintf(r.errOut, resources.ErrRedaction, err)
+	} else {
+		r.redactor = redactor
+	}
+	// Update current model to the new default model
+	r.model = r.cfg.DefaultModel
+	// Personas belong to the config, so start again with its default system prompt
+	r.persona = resources.DefaultPersonaName
+	r.systemPrompt = r.cfg.SystemPrompt
+
+	// The new config may render or encrypt history differently
+	r.openStores()
+}
+
+func (r *repl) selectPersona(args string) {
+	name := args
+	if strings.EqualFold(name, resources.DefaultPersonaName) {
+		name = ""
+	}
+	if args == "" {
+		selected, err := selectPersona(r.in, r.out, r.cfg)
+		if err != nil {
+			fmt.Fprintf(r.out, resources.InfoPersonaUnchanged, r.persona)
+			fmt.Fprintln(r.out) // Add a blank line
+			return
+		}
+		name = selected
+	}
+
+	configured, prompt, ok := r.cfg.Persona(name)
+	if !ok {
+		fmt.Fprintf(r.errOut, resources.ErrUnknownPersona+"\n", name)
+		fmt.Fprintln(r.out) // Add a blank line
+		return
+	}
+	r.systemPrompt = prompt
+	r.persona = configured
+	if r.persona == "" {
+		r.persona = resources.DefaultPersonaName
+	}
+	fmt.Fprintf(r.out, resources.InfoPersonaSelected, r.persona)
+	fmt.Fprintln(r.out) // Add a blank line
+}
+
+func (r *repl) newConversation(string) {
+	r.messages = nil
+	r.record = nil
+	r.current = nil
+	fmt.Fprint(r.out, resources.InfoNewConversation)
+	fmt.Fprintln(r.out) // Add a blank line
+}
+
+func (r *repl) setMode(args string) {
+	switch strings.ToLower(args) {
+	case "":
+		r.conversational = !r.conversational
+	case "oneshot", "one-shot":
+		r.conversational = false
+	case "conversation", "chat":
+		r.conversational = true
+	default:
+		fmt.Fprintln(r.errOut, resources.UsageMode)
+		fmt.Fprintln(r.out) // Add a blank line
+		return
+	}
+
+	r.messages = nil
+	r.record = nil
+	r.current = nil
+	if r.conversational {
+		fmt.Fprint(r.out, resources.InfoModeConversation)
+	} else {
+		fmt.Fprint(r.out, resources.InfoModeOneShot)
+	}
+	fmt.Fprintln(r.out) // Add a blank line
+}
+
+func (r *repl) setIncognito(args string) {
+	incognito := r.incognito
+	switch strings.ToLower(args) {
+	case "":
+		incognito = !r.incognito
+	case "on":
+		incognito = true
+	case "off":
+		incognito = false
+	default:
+		fmt.Fprintln(r.errOut, resources.UsageIncognito)
+		fmt.Fprintln(r.out) // Add a blank line
+		return
+	}
+
+	switch {
+	case incognito && !r.incognito:
+		// The current session is no longer kept up to date
+		r.close()
+		r.incognito = true
+	case !incognito && r.incognito:
+		r.incognito = false
+		r.openStores()
+	}
+
+	if r.incognito {
+		fmt.Fprint(r.out, resources.InfoIncognitoOn)
+	} else {
+		fmt.Fprint(r.out, resources.InfoIncognitoOff)
+		// Messages from the incognito part must not end up in the history with the next turn
+		if len(r.messages) > 0 {
+			r.messages = nil
+			r.record = nil
+			r.current = nil
+			fmt.Fprint(r.out, resources.InfoNewConversation)
+		}
+	}
+	fmt.Fprintln(r.out) // Add a blank line
+}
+
+func (r *repl) set(args string) {
+	if err := setParameter(r.out, &r.overrides, args, r.cfg.ParametersFor(r.model)); err != nil {
+		fmt.Fprintln(r.errOut, err)
+	}
+	fmt.Fprintln(r.out) // Add a blank line
+}
+
+// help prints the command table
+func (r *repl) help(string) {
+	usages := make([]string, len(commands))
+	width := 0
+	for i, cmd := range commands {
+		usage := "/" + cmd.name
+		if cmd.args != "" {
+			usage += " " + cmd.args
+		}
+		var aliases []string
+		for _, alias := range cmd.aliases {
+			aliases = append(aliases, "/"+alias)
+		}
+		if len(aliases) > 0 {
+			usage += " (" + strings.Join(aliases, ", ") + ")"
+		}
+		usages[i] = usage
+		width = max(width, len(usage))
+	}
+
+	fmt.Fprintln(r.out, resources.HelpHeader)
+	for i, cmd := range commands {
+		fmt.Fprintf(r.out, "%-*s  %s\n", width, usages[i], cmd.help)
+	}
+	fmt.Fprintln(r.out, resources.HelpFooter)
+	if !r.cfg.DisableShortcuts {
+		var shortcuts []string
+		for _, cmd := range commands {
+			if cmd.shortcut != "" {
+				shortcuts = append(shortcuts, cmd.shortcut)
+			}
+		}
+		fmt.Fprintf(r.out, resources.HelpShortcuts+"\n", strings.Join(shortcuts, ", "))
+	}
+	fmt.Fprintln(r.out) // Add a blank line
+}
+
+func (r *repl) exit(string) {
+	fmt.Fprintln(r.out, resources.GoodbyeMessage)
+	r.quit = true
+}
@@ -0,0 +1,85 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"groq-cli-chat/internal/config"
+	"groq-cli-chat/internal/groq"
+	"groq-cli-chat/resources"
+)
+
+// hints holds the advice for failed requests; the actions suggested depend on
+// where the request was made
+type hints struct {
+	modelUnavailable string
+	contextLength    string
+	rateLimit        string
+	rateLimitWait    string // Formatted with the seconds to wait
+}
+
+// replHints suggest REPL commands, which work whether or not shortcuts are enabled
+var replHints = hints{
+	modelUnavailable: resources.HintModelUnavailable,
+	contextLength:    resources.HintContextLength,
+	rateLimit:        resources.HintRateLimit,
+	rateLimitWait:    resources.HintRateLimitWait,
+}
+
+// askHints suggest flags of the ask command
+var askHints = hints{
+	modelUnavailable: resources.HintAskModelUnavailable,
+	contextLength:    resources.HintAskContextLength,
+	rateLimit:        resources.HintAskRateLimit,
+	rateLimitWait:    resources.HintAskRateLimitWait,
+}
+
+// errorHint returns advice for a failed request, or an empty string if there is none
+func errorHint(err error, cfg *config.Config, h hints) string {
+	var apiErr *groq.APIError
+	if !errors.As(err, &apiErr) {
+		return ""
+	}
+
+	switch {
+	case apiErr.IsAuth():
+		return fmt.Sprintf(resources.HintAuth, cfg.APIKeyName)
+	case apiErr.IsRateLimit():
+		if wait := apiErr.RateLimit.RetryAfter; wait > 0 {
+			return fmt.Sprintf(h.rateLimitWait, wait.Seconds())
+		}
+		return h.rateLimit
+	case apiErr.IsContextLength():
+		return h.contextLength
+	case apiErr.IsModelUnavailable():
+		return h.modelUnavailable
+	}
+	return ""
+}
+
+// printErrorHint completes an error line with a hint and the request ID, if known
+func printErrorHint(w io.Writer, err error, cfg *config.Config, h hints) {
+	if hint := errorHint(err, cfg, h); hint != "" {
+		fmt.Fprintf(w, "\n"+resources.HintFormat, hint)
+	}
+
+	var apiErr *groq.APIError
+	if errors.As(err, &apiErr) && apiErr.RequestID != "" {
+		fmt.Fprintf(w, "\n"+resources.InfoRequestID, apiErr.RequestID)
+	}
+}
+
+// exitCode maps a failed request to the exit code of Ask
+func exitCode(err error) int {
+	var apiErr *groq.APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.IsAuth():
+			return ExitAuthError
+		case apiErr.IsRateLimit():
+			return ExitRateLimited
+		}
+	}
+	return ExitAPIError
+}
@@ -1,52 +1,187 @@
 package chat
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
-	"sort"
-	"strings"
-	"time"
 
+	"groq-cli-chat/internal/config"
+	"groq-cli-chat/internal/groq"
+	"groq-cli-chat/internal/history"
+	"groq-cli-chat/internal/redact"
 	"groq-cli-chat/resources"
 )
 
-// ListChatHistory retrieves and displays a list of saved chat history files
-func ListChatHistory() error {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf(resources.ErrHomeDir, err)
+// passphraseAttempts limits how often a passphrase is asked for on the terminal
+const passphraseAttempts = 3
+
+// openHistory opens the chat history store configured by cfg. A wrong
+// passphrase typed on the terminal is asked for again.
+func openHistory(cfg *config.Config) (*history.Store, error) {
+	for attempt := 1; ; attempt++ {
+		var entered string
+		store, err := history.Open(history.Options{
+			Markdown:   cfg.WriteHistoryMarkdown(),
+			Encrypt:    cfg.HistoryEncryption.Enabled,
+			Passphrase: historyPassphrase(cfg, &entered),
+		})
+		// A passphrase from the environment would fail again, so it is not retried
+		if errors.Is(err, history.ErrWrongPassphrase) && entered != "" && attempt < passphraseAttempts {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf(resources.ErrOpenHistory, err)
+		}
+		// A wrong passphrase is not remembered, so the next attempt asks again
+		if entered != "" {
+			passphrase = entered
+		}
+		return store, nil
 	}
+}
 
-	historyDir := filepath.Join(homeDir, ".groq-chat", "history")
-	entries, err := os.ReadDir(historyDir)
+// applyRetention prunes the history according to the history_retention config.
+// Problems are reported but never stop the chat.
+func applyRetention(store *history.Store, cfg *config.Config) {
+	policy, err := cfg.HistoryRetention.Policy()
 	if err != nil {
-		if os.IsNotExist(err) {
-			fmt.Println("No chat history found.")
-			return nil
-		}
-		return fmt.Errorf(resources.ErrReadHistoryDir, err)
+		fmt.Fprintf(os.Stderr, resources.ErrHistoryRetention+"\n", err)
+		return
+	}
+	pruned, size, err := store.Prune(policy, false)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
 	}
+	if len(pruned) > 0 {
+		fmt.Fprintf(os.Stderr, resources.InfoPruned, len(pruned), history.FormatSize(size))
+	}
+}
 
-	var historyFiles []string
-	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".md") {
-			historyFiles = append(historyFiles, entry.Name())
+// PruneHistory removes old history entries, or only lists them with dryRun.
+// A policy without limits falls back to the history_retention config.
+func PruneHistory(cfg *config.Config, policy history.Policy, dryRun bool) error {
+	if policy.IsZero() {
+		var err error
+		if policy, err = cfg.HistoryRetention.Policy(); err != nil {
+			return fmt.Errorf(resources.ErrHistoryRetention, err)
+		}
+		if policy.IsZero() {
+			return fmt.Errorf(resources.ErrNoPruneLimits)
 		}
 	}
 
-	if len(historyFiles) == 0 {
-		fmt.Println("No chat history found.")
+	store, err := openHistory(cfg)
+	if err != nil {
+		return err
+	}
+	pruned, size, err := store.Prune(policy, dryRun)
+	if err != nil {
+		return err
+	}
+
+	summary := fmt.Sprintf(resources.PruneRemoved, len(pruned), history.FormatSize(size))
+	if dryRun {
+		summary = fmt.Sprintf(resources.PruneWouldRemove, len(pruned), history.FormatSize(size))
+	}
+	if len(pruned) > 0 {
+		fmt.Printf(resources.PruneListHeader, summary)
+		for i, rec := range pruned {
+			fmt.Printf(resources.HistoryListFormat, i, rec.Time.Format("2006-01-02 15:04:05"), rec.Model, truncate(rec.Prompt(), 60))
+		}
 		return nil
 	}
+	fmt.Println(summary)
+	return nil
+}
 
-	sort.Strings(historyFiles)
-	fmt.Println("────────┤ Chat History ├─────────")
-	for i, file := range historyFiles {
-		timestamp := strings.TrimPrefix(strings.TrimSuffix(file, ".md"), "chat_")
-		parsedTime, _ := time.Parse("20060102_150405", timestamp)
-		fmt.Printf("%d - %s (%s)\n", i, file, parsedTime.Format(time.RFC1123))
+// ListChatHistory writes a numbered list of saved chats, oldest first
+func ListChatHistory(w io.Writer, records []*history.Record) {
+	if len(records) == 0 {
+		fmt.Fprintln(w, "No chat history found.")
+		return
 	}
 
+	fmt.Fprintln(w, "────────┤ Chat History ├─────────")
+	for i, rec := range records {
+		fmt.Fprintf(w, resources.HistoryListFormat, i, rec.Time.Format("2006-01-02 15:04:05"), rec.Model, truncate(rec.Prompt(), 60))
+	}
+}
+
+// saveChatHistory records an exchange and returns the saved record. sent holds
+// the messages sent without the system prompt, which is stored separately and
+// prepended here.
+func saveChatHistory(store *history.Store, cfg *config.Config, red *redact.Redactor, model, systemPrompt string,
+	sent []groq.Message, resp *groq.ChatResponse, params groq.Parameters) (*history.Record, error) {
+	rec := &history.Record{
+		Provider:     cfg.ProviderName,
+		Model:        model,
+		ConfigPath:   cfg.ConfigPath,
+		SystemPrompt: redactTextForDisk(red, systemPrompt),
+		Parameters:   params,
+		Messages:     redactForDisk(red, conversation(systemPrompt, sent, resp)),
+		Usage:        resp.Usage,
+		RequestID:    resp.RequestID(),
+	}
+	return rec, store.Save(rec)
+}
+
+// appendChatHistory updates the record of a conversation with its latest turn.
+// sent holds the whole conversation sent, without the system prompt.
+func appendChatHistory(store *history.Store, red *redact.Redactor, rec *history.Record, model, systemPrompt string,
+	sent []groq.Message, resp *groq.ChatResponse, params groq.Parameters) error {
+	rec.Model = model
+	rec.SystemPrompt = redactTextForDisk(red, systemPrompt)
+	rec.Parameters = params
+	rec.Messages = redactForDisk(red, conversation(systemPrompt, sent, resp))
+	rec.Usage.Add(resp.Usage)
+	rec.RequestID = resp.RequestID()
+	return store.Update(rec)
+}
+
+// conversation returns the messages to record: the system prompt, the messages sent and the response
+func conversation(systemPrompt string, sent []groq.Message, resp *groq.ChatResponse) []groq.Message {
+	var messages []groq.Message
+	if systemPrompt != "" {
+		messages = append(messages, groq.Message{Role: groq.RoleSystem, Content: systemPrompt})
+	}
+	messages = append(messages, sent...)
+	return append(messages, resp.Choices[0].Message)
+}
+
+// ExportHistory writes the history entries matching q to output, or to stdout
+// when output is empty. Without a format it is guessed from the file name.
+func ExportHistory(cfg *config.Config, q history.Query, format, output string) error {
+	store, err := openHistory(cfg)
+	if err != nil {
+		return err
+	}
+	records, err := store.Find(q)
+	if err != nil {
+		return err
+	}
+	if format == "" {
+		format = history.FormatForFile(output)
+	}
+
+	if output == "" {
+		return history.Export(os.Stdout, records, format)
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf(resources.ErrWriteExport, err)
+	}
+	if err := history.Export(f, records, format); err != nil {
+		f.Close()
+		os.Remove(output)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf(resources.ErrWriteExport, err)
+	}
+	fmt.Fprintf(os.Stderr, resources.InfoExported, output, len(records))
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,145 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+
+	"groq-cli-chat/resources"
+)
+
+// interruptHandler routes Ctrl-C to the in-flight request, if any.
+// At the prompt the first Ctrl-C shows a hint and the second one quits: the
+// input reads end, so the REPL loop exits and cleans up as if it read EOF.
+type interruptHandler struct {
+	mu      sync.Mutex
+	cancel  context.CancelFunc // Cancels the in-flight request, nil at the prompt
+	pending bool               // Ctrl-C was pressed once at the prompt
+	out     io.Writer          // Where the hint is shown
+	quit    chan struct{}      // Closed by the second Ctrl-C at the prompt
+}
+
+// newInterruptHandler returns the interrupt handler for the REPL, showing its hint on out
+func newInterruptHandler(out io.Writer) *interruptHandler {
+	return &interruptHandler{out: out, quit: make(chan struct{})}
+}
+
+// notify installs the SIGINT handler, routing Ctrl-C to h
+func (h *interruptHandler) notify() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		for range signals {
+			h.handle()
+		}
+	}()
+}
+
+func (h *interruptHandler) handle() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.cancel != nil {
+		h.cancel()
+		h.cancel = nil
+		return
+	}
+
+	if h.pending {
+		if !h.quitting() {
+			close(h.quit)
+		}
+		return
+	}
+	h.pending = true
+	fmt.Fprint(h.out, resources.InfoInterruptHint)
+}
+
+// quitting reports whether the user quit with Ctrl-C
+func (h *interruptHandler) quitting() bool {
+	select {
+	case <-h.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// input wraps the REPL input so that reads report EOF once the user quits with Ctrl-C
+func (h *interruptHandler) input(r io.Reader) io.Reader {
+	return &quitReader{r: r, quit: h.quit, results: make(chan readResult, 1)}
+}
+
+// quitReader reads in the background so that a read blocked on the terminal
+// can be abandoned when quit is closed
+type quitReader struct {
+	r       io.Reader
+	quit    <-chan struct{}
+	results chan readResult
+	pending bool // A background read is in progress
+}
+
+// readResult is the outcome of a background read
+type readResult struct {
+	data []byte
+	err  error
+}
+
+func (q *quitReader) Read(p []byte) (int, error) {
+	if q.quitting() {
+		return 0, io.EOF
+	}
+	if !q.pending {
+		q.pending = true
+		buf := make([]byte, len(p))
+		go func() {
+			n, err := q.r.Read(buf)
+			q.results <- readResult{data: buf[:n], err: err}
+		}()
+	}
+
+	select {
+	case res := <-q.results:
+		q.pending = false
+		return copy(p, res.data), res.err
+	case <-q.quit:
+		return 0, io.EOF
+	}
+}
+
+func (q *quitReader) quitting() bool {
+	select {
+	case <-q.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// requestContext returns a context cancelled by the next Ctrl-C.
+// The returned function must be called once the request is finished.
+func (h *interruptHandler) requestContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	h.mu.Lock()
+	h.cancel = cancel
+	h.pending = false
+	h.mu.Unlock()
+
+	return ctx, func() {
+		h.mu.Lock()
+		h.cancel = nil
+		h.mu.Unlock()
+		cancel()
+	}
+}
+
+// reset forgets a previous Ctrl-C at the prompt once the user enters a line
+func (h *interruptHandler) reset() {
+	h.mu.Lock()
+	h.pending = false
+	h.mu.Unlock()
+}
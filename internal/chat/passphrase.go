@@ -0,0 +1,109 @@
+package chat
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+	"groq-cli-chat/internal/config"
+	"groq-cli-chat/resources"
+)
+
+// defaultPassphraseEnv holds the history passphrase unless the config names another variable
+const defaultPassphraseEnv = "GROQ_CHAT_HISTORY_PASSPHRASE"
+
+// passphrase is remembered once it unlocked the history, so reopening the
+// history, e.g. after /config, does not ask again
+var passphrase string
+
+// historyPassphrase returns the passphrase callback for the history store: the
+// passphrase comes from the configured environment variable or the terminal.
+// A passphrase read from the terminal is stored in entered, to be remembered
+// once the store has been opened with it.
+func historyPassphrase(cfg *config.Config, entered *string) func(confirm bool) (string, error) {
+	return func(confirm bool) (string, error) {
+		env := cfg.HistoryEncryption.PassphraseEnv
+		if env == "" {
+			env = defaultPassphraseEnv
+		}
+		if value := os.Getenv(env); value != "" {
+			return value, nil
+		}
+		if passphrase != "" {
+			return passphrase, nil
+		}
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return "", fmt.Errorf(resources.ErrNoPassphrase, env)
+		}
+
+		read := readPassphrase
+		if confirm {
+			read = readNewPassphrase
+		}
+		value, err := read(resources.PassphrasePrompt)
+		if err != nil {
+			return "", err
+		}
+		*entered = value
+		return value, nil
+	}
+}
+
+// readPassphrase asks for a passphrase on the terminal without echoing it
+func readPassphrase(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf(resources.ErrReadInput)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	value, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	return string(value), err
+}
+
+// readNewPassphrase asks for a new passphrase twice
+func readNewPassphrase(prompt string) (string, error) {
+	value, err := readPassphrase(prompt)
+	if err != nil {
+		return "", err
+	}
+	repeated, err := readPassphrase(resources.RepeatPassphrase)
+	if err != nil {
+		return "", err
+	}
+	if value != repeated {
+		return "", fmt.Errorf(resources.ErrPassphraseMismatch)
+	}
+	return value, nil
+}
+
+// RekeyHistory re-encrypts the history with a new passphrase read from the terminal
+func RekeyHistory(cfg *config.Config) error {
+	store, err := openHistory(cfg)
+	if err != nil {
+		return err
+	}
+	if !store.Encrypted() {
+		return fmt.Errorf(resources.ErrHistoryNotEncrypted)
+	}
+
+	newPassphrase, err := readNewPassphrase(resources.NewPassphrasePrompt)
+	if err != nil {
+		return err
+	}
+	// Sessions are sealed with the history key, so they are re-encrypted along with it
+	sessions, err := openSessions(cfg, store, false)
+	if err != nil {
+		return err
+	}
+	if err := sessions.Reseal(func() error { return store.Rekey(newPassphrase) }); err != nil {
+		return err
+	}
+
+	records, err := store.List()
+	if err != nil {
+		return err
+	}
+	fmt.Printf(resources.InfoRekeyed, len(records))
+	return nil
+}
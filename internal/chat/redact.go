@@ -0,0 +1,49 @@
+package chat
+
+import (
+	"fmt"
+	"io"
+
+	"groq-cli-chat/internal/groq"
+	"groq-cli-chat/internal/redact"
+	"groq-cli-chat/resources"
+)
+
+// redactPrompt scans a prompt before it is sent and reports the findings.
+// In mask mode the masked prompt is returned, otherwise the prompt as-is.
+func redactPrompt(w io.Writer, red *redact.Redactor, prompt string) string {
+	masked, findings := red.Mask(prompt)
+	if len(findings) == 0 {
+		return prompt
+	}
+
+	summary := redact.Summary(findings)
+	switch red.Mode() {
+	case redact.ModeMask:
+		fmt.Fprintf(w, resources.InfoRedactedPrompt, summary)
+		return masked
+	case redact.ModeHistory:
+		fmt.Fprintf(w, resources.InfoRedactedHistory, summary)
+	default:
+		fmt.Fprintf(w, resources.WarnSecrets, summary)
+	}
+	return prompt
+}
+
+// redactForDisk masks messages that are about to be saved when the mode asks for it
+func redactForDisk(red *redact.Redactor, messages []groq.Message) []groq.Message {
+	if red.Mode() != redact.ModeHistory {
+		return messages
+	}
+	return red.MaskMessages(messages)
+}
+
+// redactTextForDisk masks a single text, such as a system prompt, that is about
+// to be saved when the mode asks for it
+func redactTextForDisk(red *redact.Redactor, text string) string {
+	if red.Mode() != redact.ModeHistory {
+		return text
+	}
+	masked, _ := red.Mask(text)
+	return masked
+}
@@ -0,0 +1,299 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"groq-cli-chat/internal/config"
+	"groq-cli-chat/internal/groq"
+	"groq-cli-chat/internal/groq/groqtest"
+	"groq-cli-chat/internal/history"
+)
+
+// testConfig returns a config with two models and a system prompt
+func testConfig() *config.Config {
+	return &config.Config{
+		ProviderName: "Test",
+		DefaultModel: "model-a",
+		Models:       []string{"model-a", "model-b"},
+		SystemPrompt: "Be brief.",
+	}
+}
+
+// runREPL runs a chat reading input line by line, with HOME in a temporary
+// directory so history and sessions stay out of the real one. It returns the
+// chat output and error output.
+func runREPL(t *testing.T, cfg *config.Config, provider groq.Provider, input ...string) (string, string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("NO_COLOR", "1")
+
+	var out, errOut bytes.Buffer
+	r, err := newREPL(cfg, provider, strings.NewReader(strings.Join(input, "\n")+"\n"), &out, &errOut)
+	if err != nil {
+		t.Fatalf("newREPL: %v", err)
+	}
+	r.loop()
+	return out.String(), errOut.String()
+}
+
+// savedRecords returns the history records written under the temporary HOME
+func savedRecords(t *testing.T) []*history.Record {
+	t.Helper()
+	store, err := history.Open(history.Options{Dir: filepath.Join(os.Getenv("HOME"), ".groq-chat", "history")})
+	if err != nil {
+		t.Fatalf("open history: %v", err)
+	}
+	records, err := store.List()
+	if err != nil {
+		t.Fatalf("list history: %v", err)
+	}
+	return records
+}
+
+func TestREPLOneShot(t *testing.T) {
+	provider := groqtest.New("model-a", "model-b")
+	provider.Reply = func(req groqtest.Request) string { return "pong" }
+	provider.Usage = groq.Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5}
+
+	out, errOut := runREPL(t, testConfig(), provider, "ping", "again", "/q")
+
+	requests := provider.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+	req := requests[1]
+	if req.Model != "model-a" || !req.Stream {
+		t.Errorf("request model %q, stream %v; want model-a streamed", req.Model, req.Stream)
+	}
+	// One-shot prompts are sent alone after the system prompt
+	want := []groq.Message{{Role: groq.RoleSystem, Content: "Be brief."}, {Role: groq.RoleUser, Content: "again"}}
+	if !slices.Equal(req.Messages, want) {
+		t.Errorf("messages = %v, want %v", req.Messages, want)
+	}
+
+	if !strings.Contains(out, "pong") || !strings.Contains(out, "5 tokens") {
+		t.Errorf("output misses the reply or its stats:\n%s", out)
+	}
+	if errOut != "" {
+		t.Errorf("unexpected errors: %s", errOut)
+	}
+	if records := savedRecords(t); len(records) != 2 || records[0].Response() != "pong" {
+		t.Errorf("history has %d records, want 2 answered with pong", len(records))
+	}
+}
+
+func TestREPLConversation(t *testing.T) {
+	provider := groqtest.New("model-a", "model-b")
+
+	runREPL(t, testConfig(), provider, "/mode conversation", "first", "second", "/q")
+
+	requests := provider.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+	want := []groq.Message{
+		{Role: groq.RoleSystem, Content: "Be brief."},
+		{Role: groq.RoleUser, Content: "first"},
+		{Role: groq.RoleAssistant, Content: "first"},
+		{Role: groq.RoleUser, Content: "second"},
+	}
+	if !slices.Equal(requests[1].Messages, want) {
+		t.Errorf("messages = %v, want %v", requests[1].Messages, want)
+	}
+
+	// Both turns are kept in a single history record
+	records := savedRecords(t)
+	if len(records) != 1 || records[0].Turns() != 2 {
+		t.Fatalf("history has %d records, want 1 with 2 turns", len(records))
+	}
+}
+
+func TestREPLCommands(t *testing.T) {
+	provider := groqtest.New("model-a", "model-b")
+
+	out, errOut := runREPL(t, testConfig(), provider,
+		"/model model-b", "/set temperature 0.5", "/nope", `\/help`, "q")
+
+	requests := provider.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+	req := requests[0]
+	if req.Model != "model-b" {
+		t.Errorf("model = %q, want model-b", req.Model)
+	}
+	if req.Parameters.Temperature == nil || *req.Parameters.Temperature != 0.5 {
+		t.Errorf("temperature = %v, want 0.5", req.Parameters.Temperature)
+	}
+	// A leading backslash sends the line as a prompt
+	if last := req.Messages[len(req.Messages)-1].Content; last != "/help" {
+		t.Errorf("prompt = %q, want /help", last)
+	}
+
+	if !strings.Contains(errOut, "nope") {
+		t.Errorf("unknown command not reported: %q", errOut)
+	}
+	if !strings.Contains(out, "temperature=0.5") {
+		t.Errorf("parameters not shown:\n%s", out)
+	}
+}
+
+func TestREPLIncognito(t *testing.T) {
+	cfg := testConfig()
+	cfg.Incognito = true
+	provider := groqtest.New("model-a")
+
+	runREPL(t, cfg, provider, "secret", "/q")
+
+	if len(provider.Requests()) != 1 {
+		t.Fatalf("got %d requests, want 1", len(provider.Requests()))
+	}
+	if _, err := os.Stat(filepath.Join(os.Getenv("HOME"), ".groq-chat")); !os.IsNotExist(err) {
+		t.Errorf("incognito chat wrote to disk: %v", err)
+	}
+}
+
+func TestREPLProviderError(t *testing.T) {
+	provider := groqtest.New("model-a")
+	provider.Err = errors.New("boom")
+
+	out, errOut := runREPL(t, testConfig(), provider, "hello", "/q")
+
+	if !strings.Contains(errOut, "boom") {
+		t.Errorf("error not reported: %q", errOut)
+	}
+	if strings.Contains(out, "Stats") {
+		t.Errorf("stats printed for a failed request:\n%s", out)
+	}
+	if records := savedRecords(t); len(records) != 0 {
+		t.Errorf("failed request saved to history: %d records", len(records))
+	}
+}
+
+func TestRetryNote(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts++; attempts == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"data":[{"id":"model-a"}]}`))
+	}))
+	defer server.Close()
+
+	cfg := testConfig()
+	cfg.BaseURL = server.URL
+	var errOut bytes.Buffer
+	provider, err := newProvider(cfg, &errOut)
+	if err != nil {
+		t.Fatalf("newProvider: %v", err)
+	}
+	if _, err := provider.ListModels(context.Background()); err != nil {
+		t.Fatalf("ListModels: %v", err)
+	}
+	// Retries are reported on the error writer, not the terminal
+	if !strings.Contains(errOut.String(), "429") || !strings.Contains(errOut.String(), "attempt 2/3") {
+		t.Errorf("retry note = %q, want the status and attempt", errOut.String())
+	}
+}
+
+func TestREPLInterruptQuit(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("NO_COLOR", "1")
+
+	// The input never ends, as on a terminal
+	in, input := io.Pipe()
+	defer input.Close()
+	var out lockedBuffer
+	r, err := newREPL(testConfig(), groqtest.New("model-a"), in, &out, io.Discard)
+	if err != nil {
+		t.Fatalf("newREPL: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.loop()
+		close(done)
+	}()
+	// Ctrl-C twice at the prompt
+	r.interrupts.handle()
+	r.interrupts.handle()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the REPL did not quit after Ctrl-C twice")
+	}
+	if !strings.Contains(out.String(), "Ctrl-C again") || !strings.Contains(out.String(), "Goodbye") {
+		t.Errorf("output misses the hint or goodbye:\n%s", out.String())
+	}
+}
+
+// lockedBuffer is a buffer written by the REPL and the interrupt handler at the same time
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestREPLShortcuts(t *testing.T) {
+	provider := groqtest.New("model-a", "model-b")
+
+	// Only the letters that predate slash commands are shortcuts, and only in lower case
+	runREPL(t, testConfig(), provider, "n", "t", "p", "?", "Q", "I", "q", "never sent")
+
+	var prompts []string
+	for _, req := range provider.Requests() {
+		prompts = append(prompts, req.Messages[len(req.Messages)-1].Content)
+	}
+	if want := []string{"n", "t", "p", "?", "Q", "I"}; !slices.Equal(prompts, want) {
+		t.Errorf("prompts = %q, want %q", prompts, want)
+	}
+}
+
+func TestREPLPersona(t *testing.T) {
+	cfg := testConfig()
+	// Viper lowercases the persona names read from the config file
+	cfg.Personas = map[string]string{"devops": "You are a DevOps engineer."}
+	provider := groqtest.New("model-a")
+
+	out, errOut := runREPL(t, cfg, provider, "/persona DevOps", "hi", "/persona Default", "again", "/q")
+
+	requests := provider.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+	if got := requests[0].Messages[0].Content; got != "You are a DevOps engineer." {
+		t.Errorf("system prompt = %q, want the devops persona", got)
+	}
+	if got := requests[1].Messages[0].Content; got != "Be brief." {
+		t.Errorf("system prompt = %q, want the default one", got)
+	}
+	if !strings.Contains(out, "Persona: devops") || errOut != "" {
+		t.Errorf("persona not selected by its configured name:\n%s%s", out, errOut)
+	}
+}
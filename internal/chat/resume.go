@@ -0,0 +1,83 @@
+package chat
+
+import (
+	"fmt"
+
+	"groq-cli-chat/internal/config"
+	"groq-cli-chat/internal/history"
+	"groq-cli-chat/resources"
+)
+
+// resume continues a saved conversation, the most recent one without an ID
+func (r *repl) resume(args string) {
+	if err := r.resumeSession(args); err != nil {
+		fmt.Fprintln(r.errOut, err)
+		fmt.Fprintln(r.out) // Add a blank line
+	}
+}
+
+// resumeSession loads the history record with the given ID, or the most recent one
+func (r *repl) resumeSession(id string) error {
+	if r.store == nil {
+		return fmt.Errorf(resources.ErrOpenHistory, resources.ErrHistoryUnavailable)
+	}
+
+	var rec *history.Record
+	if id != "" {
+		var err error
+		if rec, err = r.store.Get(id); err != nil {
+			return err
+		}
+	} else {
+		records, err := r.store.List()
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return fmt.Errorf(resources.ErrNoHistory)
+		}
+		rec = records[len(records)-1]
+	}
+
+	r.resumeRecord(rec)
+	return nil
+}
+
+// resumeRecord restores the model, system prompt, parameters and messages of a
+// saved conversation. Following turns are appended to the same record.
+func (r *repl) resumeRecord(rec *history.Record) {
+	if !config.IsValidModel(rec.Model, r.cfg.Models) {
+		fmt.Fprintf(r.errOut, resources.WarnResumeModel, rec.Model)
+	}
+	r.model = rec.Model
+
+	r.systemPrompt = rec.SystemPrompt
+	if name, ok := r.cfg.PersonaFor(rec.SystemPrompt); !ok {
+		r.persona = resources.HistoryPersonaName
+	} else if name == "" {
+		r.persona = resources.DefaultPersonaName
+	} else {
+		r.persona = name
+	}
+
+	// The recorded parameters are the ones in effect, so they override the config
+	r.overrides = rec.Parameters
+	r.messages = rec.History()
+	r.conversational = true
+	r.record = rec
+	// The resumed conversation is autosaved as a new session
+	r.current = nil
+
+	fmt.Fprintf(r.out, resources.InfoResumed, rec.ID, rec.Model, rec.Turns())
+	r.printRestored(rec.Response())
+	fmt.Fprintln(r.out) // Add a blank line
+}
+
+// printRestored shows the persona, parameters and last response of a restored chat
+func (r *repl) printRestored(lastResponse string) {
+	fmt.Fprintf(r.out, resources.InfoPersonaSelected, r.persona)
+	fmt.Fprintf(r.out, resources.InfoParameters, describeParameters(r.cfg.ParametersFor(r.model).Merge(r.overrides)))
+	if lastResponse != "" {
+		fmt.Fprintf(r.out, resources.InfoLastResponse, truncate(lastResponse, 200))
+	}
+}
@@ -0,0 +1,225 @@
+package chat
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"groq-cli-chat/internal/config"
+	"groq-cli-chat/internal/history"
+	"groq-cli-chat/resources"
+)
+
+// ANSI sequences used to highlight search hits on a terminal
+const (
+	highlightStart = "\033[1;7m"
+	highlightEnd   = "\033[0m"
+)
+
+// HistoryFilter holds the history selection flags shared by the CLI and the REPL
+type HistoryFilter struct {
+	Regex    bool
+	Model    string
+	Provider string
+	Since    string
+	Until    string
+}
+
+// AddFlags registers the filter flags on fs
+func (f *HistoryFilter) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVarP(&f.Regex, "regex", "r", false, "treat the search text as a regular expression")
+	fs.StringVarP(&f.Model, "model", "m", "", "only entries whose model contains this text")
+	fs.StringVarP(&f.Provider, "provider", "p", "", "only entries from this provider")
+	fs.StringVar(&f.Since, "since", "", "only entries from this date on (YYYY-MM-DD or an age such as 7d)")
+	fs.StringVar(&f.Until, "until", "", "only entries up to this date (YYYY-MM-DD or an age such as 7d)")
+}
+
+// Query builds the history query for text with the filter applied
+func (f *HistoryFilter) Query(text string) (history.Query, error) {
+	since, err := history.ParseTime(f.Since, false)
+	if err != nil {
+		return history.Query{}, err
+	}
+	until, err := history.ParseTime(f.Until, true)
+	if err != nil {
+		return history.Query{}, err
+	}
+	return history.Query{
+		Text:     text,
+		Regex:    f.Regex,
+		Model:    f.Model,
+		Provider: f.Provider,
+		Since:    since,
+		Until:    until,
+	}, nil
+}
+
+// SearchHistory prints the history entries matching q to stdout
+func SearchHistory(cfg *config.Config, q history.Query) error {
+	store, err := openHistory(cfg)
+	if err != nil {
+		return err
+	}
+	matches, err := store.Search(q)
+	if err != nil {
+		return err
+	}
+	printMatches(os.Stdout, matches, useColor(os.Stdout))
+	return nil
+}
+
+// ShowHistory prints a single history entry to stdout
+func ShowHistory(cfg *config.Config, id string) error {
+	store, err := openHistory(cfg)
+	if err != nil {
+		return err
+	}
+	rec, err := store.Get(id)
+	if err != nil {
+		return err
+	}
+	printHistoryEntry(os.Stdout, rec)
+	return nil
+}
+
+// search finds history entries and lets the user open one of them
+func (r *repl) search(args string) {
+	if r.store == nil {
+		fmt.Fprintf(r.errOut, resources.ErrOpenHistory+"\n", resources.ErrHistoryUnavailable)
+		fmt.Fprintln(r.out) // Add a blank line
+		return
+	}
+
+	var filter HistoryFilter
+	fs := pflag.NewFlagSet("search", pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	filter.AddFlags(fs)
+	flags, text := splitSearchArgs(fs, args)
+	if err := fs.Parse(flags); err != nil {
+		fmt.Fprintf(r.errOut, "%v\n%s\n", err, resources.UsageSearch)
+		fmt.Fprintln(r.out) // Add a blank line
+		return
+	}
+
+	q, err := filter.Query(text)
+	if err == nil && q.Text == "" {
+		err = fmt.Errorf(resources.UsageSearch)
+	}
+	var matches []history.Match
+	if err == nil {
+		matches, err = r.store.Search(q)
+	}
+	if err != nil {
+		fmt.Fprintln(r.errOut, err)
+		fmt.Fprintln(r.out) // Add a blank line
+		return
+	}
+
+	printMatches(r.out, matches, useColor(r.out))
+	if len(matches) == 0 {
+		fmt.Fprintln(r.out) // Add a blank line
+		return
+	}
+
+	fmt.Fprintf(r.out, resources.SelectSearchResultPrompt, len(matches)-1)
+	choice, err := r.readLine()
+	if err != nil || choice == "" || strings.EqualFold(choice, "q") {
+		fmt.Fprintln(r.out) // Add a blank line
+		return
+	}
+	index, err := parseChoice(choice, len(matches))
+	if err != nil {
+		fmt.Fprintln(r.errOut, err)
+		fmt.Fprintln(r.out) // Add a blank line
+		return
+	}
+
+	r.viewHistoryEntry(matches[index].Record)
+}
+
+// splitSearchArgs separates the leading flags of /search from the search text.
+// The text is kept verbatim, so spaces in phrases and regular expressions
+// survive; it may be quoted, and "--" ends the flags, e.g. to search for "-x".
+func splitSearchArgs(fs *pflag.FlagSet, args string) ([]string, string) {
+	var flags []string
+	rest := strings.TrimSpace(args)
+	for strings.HasPrefix(rest, "-") {
+		token, after, _ := strings.Cut(rest, " ")
+		rest = strings.TrimSpace(after)
+		if token == "--" {
+			break
+		}
+		flags = append(flags, token)
+		if !strings.Contains(token, "=") && takesValue(fs, token) {
+			value, after, _ := strings.Cut(rest, " ")
+			flags = append(flags, value)
+			rest = strings.TrimSpace(after)
+		}
+	}
+
+	if len(rest) >= 2 && (rest[0] == '"' || rest[0] == '\'') && rest[len(rest)-1] == rest[0] {
+		rest = rest[1 : len(rest)-1]
+	}
+	return flags, rest
+}
+
+// takesValue reports whether the flag token, e.g. --model or -m, is followed by a value
+func takesValue(fs *pflag.FlagSet, token string) bool {
+	var flag *pflag.Flag
+	if name, ok := strings.CutPrefix(token, "--"); ok {
+		flag = fs.Lookup(name)
+	} else {
+		// The last of combined shorthands, e.g. -rm, takes the value
+		flag = fs.ShorthandLookup(token[len(token)-1:])
+	}
+	return flag != nil && flag.NoOptDefVal == ""
+}
+
+// printMatches lists search results with their snippets, numbered from 0
+func printMatches(w io.Writer, matches []history.Match, color bool) {
+	if len(matches) == 0 {
+		fmt.Fprintln(w, resources.InfoNoSearchResults)
+		return
+	}
+
+	fmt.Fprintf(w, resources.SearchResultsHeader, len(matches))
+	for i, match := range matches {
+		rec := match.Record
+		fmt.Fprintf(w, resources.SearchResultFormat, i, rec.Time.Format("2006-01-02 15:04:05"), rec.Model, rec.ID,
+			match.Role, highlight(match.Snippet, match.Highlights, color))
+	}
+}
+
+// highlight marks the given byte ranges of s, or returns s unchanged without color
+func highlight(s string, ranges [][2]int, color bool) string {
+	if !color || len(ranges) == 0 {
+		return s
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, r := range ranges {
+		if r[0] < last {
+			continue
+		}
+		b.WriteString(s[last:r[0]])
+		b.WriteString(highlightStart)
+		b.WriteString(s[r[0]:r[1]])
+		b.WriteString(highlightEnd)
+		last = r[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// useColor reports whether w is a terminal and NO_COLOR is not set
+func useColor(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,312 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+
+	"groq-cli-chat/internal/config"
+	"groq-cli-chat/internal/history"
+	"groq-cli-chat/internal/session"
+	"groq-cli-chat/resources"
+)
+
+// openSessions opens the session store. With an encrypted history, sessions are
+// sealed with its key and are not available while the history is locked.
+// In incognito the store is only read, so encrypted sessions cannot be loaded.
+func openSessions(cfg *config.Config, store *history.Store, incognito bool) (*session.Store, error) {
+	sessions, err := session.Open("")
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case incognito:
+	case store != nil && store.Encrypted():
+		if err := sessions.SetCipher(store); err != nil {
+			return nil, err
+		}
+	case cfg.HistoryEncryption.Enabled || history.IsEncrypted(""):
+		return nil, fmt.Errorf(resources.ErrSessionsLocked)
+	}
+	return sessions, nil
+}
+
+// sessionCommand handles /session <new|save|load|list|rename|delete> [args]
+func (r *repl) sessionCommand(args string) {
+	if r.sessions == nil {
+		fmt.Fprintln(r.errOut, resources.ErrSessionsUnavailable)
+		fmt.Fprintln(r.out) // Add a blank line
+		return
+	}
+
+	sub, rest, _ := strings.Cut(args, " ")
+	params := strings.Fields(rest)
+
+	var err error
+	switch strings.ToLower(sub) {
+	case "", "list", "ls":
+		err = r.listSessions()
+	case "new", "save":
+		if r.incognito {
+			err = fmt.Errorf(resources.ErrIncognito)
+		} else if sub == "new" {
+			err = r.newSession(rest)
+		} else {
+			err = r.saveSessionAs(rest)
+		}
+	case "load":
+		if len(params) != 1 {
+			err = fmt.Errorf(resources.UsageSession)
+			break
+		}
+		err = r.loadSession(params[0])
+	case "rename":
+		switch len(params) {
+		case 1:
+			if r.current == nil {
+				err = fmt.Errorf(resources.ErrNoSession)
+				break
+			}
+			err = r.renameSession(r.current.Name, params[0])
+		case 2:
+			err = r.renameSession(params[0], params[1])
+		default:
+			err = fmt.Errorf(resources.UsageSession)
+		}
+	case "delete", "rm":
+		if len(params) != 1 {
+			err = fmt.Errorf(resources.UsageSession)
+			break
+		}
+		err = r.deleteSession(params[0])
+	default:
+		err = fmt.Errorf(resources.UsageSession)
+	}
+
+	if err != nil {
+		fmt.Fprintln(r.errOut, err)
+	}
+	fmt.Fprintln(r.out) // Add a blank line
+}
+
+// saveSession writes the current chat state to the session store,
+// naming the session if it has no name yet
+func (r *repl) saveSession() error {
+	if r.current == nil {
+		r.current = &session.Session{Name: session.NewName()}
+	}
+
+	s := r.current
+	s.Model = r.model
+	s.Persona = r.persona
+	s.SystemPrompt = redactTextForDisk(r.redactor, r.systemPrompt)
+	s.Conversational = r.conversational
+	s.Parameters = r.overrides
+	s.Messages = redactForDisk(r.redactor, r.messages)
+	s.HistoryID = ""
+	if r.record != nil {
+		s.HistoryID = r.record.ID
+	}
+
+	if err := r.sessions.Save(s); err != nil {
+		return err
+	}
+	return r.sessions.SetActive(s.Name)
+}
+
+// autosave saves the session after a turn. One-shot prompts have no state worth
+// restoring, so they are only saved into a session that was started explicitly.
+func (r *repl) autosave() {
+	if r.sessions == nil || r.incognito || (r.current == nil && len(r.messages) == 0) {
+		return
+	}
+	if err := r.saveSession(); err != nil {
+		fmt.Fprintln(r.errOut, err)
+	}
+}
+
+// restoreSession replaces the chat state with a saved session
+func (r *repl) restoreSession(s *session.Session) error {
+	r.model = s.Model
+	r.systemPrompt = s.SystemPrompt
+	r.persona = s.Persona
+	if r.persona == "" {
+		r.persona = resources.DefaultPersonaName
+	}
+	r.conversational = s.Conversational
+	r.overrides = s.Parameters
+	r.messages = s.Messages
+
+	// Keep appending to the history record of the conversation
+	r.record = nil
+	if r.store != nil && s.HistoryID != "" {
+		if rec, err := r.store.Get(s.HistoryID); err == nil {
+			r.record = rec
+		}
+	}
+
+	r.current = s
+	if !r.incognito {
+		if err := r.sessions.SetActive(s.Name); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(r.out, resources.InfoSessionLoaded, s.Name, s.Model, s.Turns())
+	last := ""
+	if len(s.Messages) > 0 {
+		last = s.Messages[len(s.Messages)-1].Content
+	}
+	r.printRestored(last)
+	return nil
+}
+
+// recoverSession offers to restore the session of a chat that did not exit cleanly
+func (r *repl) recoverSession() {
+	if r.sessions == nil {
+		return
+	}
+	name, err := r.sessions.Active()
+	if err != nil || name == "" {
+		return
+	}
+	// Ask only once, whatever the answer
+	defer func() {
+		if r.current == nil {
+			r.close()
+		}
+	}()
+
+	s, err := r.sessions.Load(name)
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintf(r.out, resources.ConfirmRecoverSession, s.Name, s.Model, s.Turns(), s.Updated.Format("2006-01-02 15:04"))
+	answer, err := r.readLine()
+	if err != nil || !isYes(answer) {
+		fmt.Fprintln(r.out) // Add a blank line
+		return
+	}
+	if err := r.restoreSession(s); err != nil {
+		fmt.Fprintln(r.errOut, err)
+	}
+	fmt.Fprintln(r.out) // Add a blank line
+}
+
+// listSessions prints the saved sessions, most recent first
+func (r *repl) listSessions() error {
+	sessions, err := r.sessions.List()
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		fmt.Fprintln(r.out, resources.InfoNoSessions)
+		return nil
+	}
+
+	fmt.Fprintln(r.out, resources.SessionListHeader)
+	for _, s := range sessions {
+		marker := " "
+		if r.current != nil && r.current.Name == s.Name {
+			marker = "*"
+		}
+		fmt.Fprintf(r.out, resources.SessionListFormat, marker, s.Name, s.Updated.Format("2006-01-02 15:04"), s.Model, s.Turns())
+	}
+	return nil
+}
+
+// newSession saves the current session and starts an empty one
+func (r *repl) newSession(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = session.NewName()
+	}
+	if err := session.ValidateName(name); err != nil {
+		return err
+	}
+	if r.sessions.Exists(name) {
+		return fmt.Errorf(resources.ErrSessionExists, name)
+	}
+
+	r.autosave()
+	r.messages = nil
+	r.record = nil
+	r.current = &session.Session{Name: name}
+	if err := r.saveSession(); err != nil {
+		return err
+	}
+	fmt.Fprintf(r.out, resources.InfoSessionNew, name)
+	return nil
+}
+
+// saveSessionAs saves the current session, under a new name if one is given
+func (r *repl) saveSessionAs(name string) error {
+	name = strings.TrimSpace(name)
+	if name != "" && (r.current == nil || r.current.Name != name) {
+		if err := session.ValidateName(name); err != nil {
+			return err
+		}
+		if r.sessions.Exists(name) {
+			return fmt.Errorf(resources.ErrSessionExists, name)
+		}
+		// An autosaved session is renamed rather than copied
+		if r.current != nil && r.sessions.Exists(r.current.Name) {
+			if err := r.sessions.Rename(r.current.Name, name); err != nil {
+				return err
+			}
+		}
+		if r.current == nil {
+			r.current = &session.Session{}
+		}
+		r.current.Name = name
+	}
+
+	if err := r.saveSession(); err != nil {
+		return err
+	}
+	fmt.Fprintf(r.out, resources.InfoSessionSaved, r.current.Name)
+	return nil
+}
+
+// loadSession saves the current session and switches to the named one
+func (r *repl) loadSession(name string) error {
+	s, err := r.sessions.Load(name)
+	if err != nil {
+		return err
+	}
+	r.autosave()
+	return r.restoreSession(s)
+}
+
+func (r *repl) renameSession(oldName, newName string) error {
+	if err := r.sessions.Rename(oldName, newName); err != nil {
+		return err
+	}
+	if r.current != nil && r.current.Name == oldName {
+		r.current.Name = newName
+	}
+	fmt.Fprintf(r.out, resources.InfoSessionRenamed, oldName, newName)
+	return nil
+}
+
+func (r *repl) deleteSession(name string) error {
+	if !r.sessions.Exists(name) {
+		return fmt.Errorf(resources.ErrSessionNotFound, name)
+	}
+	fmt.Fprintf(r.out, resources.ConfirmDeleteSession, name)
+	answer, err := r.readLine()
+	if err != nil || !isYes(answer) {
+		return nil
+	}
+
+	if err := r.sessions.Delete(name); err != nil {
+		return err
+	}
+	if r.current != nil && r.current.Name == name {
+		// Keep chatting, the next turn is saved as a new session
+		r.current = nil
+		r.close()
+	}
+	fmt.Fprintf(r.out, resources.InfoSessionDeleted, name)
+	return nil
+}
@@ -0,0 +1,155 @@
+package chat
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"groq-cli-chat/internal/history"
+	"groq-cli-chat/resources"
+)
+
+// history lists the saved chats and lets the user open one of them.
+// With a number or an entry ID as argument the entry is opened directly.
+func (r *repl) history(args string) {
+	if r.store == nil {
+		fmt.Fprintf(r.errOut, resources.ErrOpenHistory+"\n", resources.ErrHistoryUnavailable)
+		fmt.Fprintln(r.out) // Add a blank line
+		return
+	}
+
+	if rec, err := r.store.Get(args); args != "" && err == nil {
+		r.viewHistoryEntry(rec)
+		return
+	}
+
+	records, err := r.store.List()
+	if err != nil {
+		fmt.Fprintln(r.errOut, err)
+		fmt.Fprintln(r.out) // Add a blank line
+		return
+	}
+
+	choice := args
+	if choice == "" {
+		ListChatHistory(r.out, records)
+		if len(records) == 0 {
+			fmt.Fprintln(r.out) // Add a blank line
+			return
+		}
+
+		fmt.Fprintf(r.out, resources.SelectHistoryPrompt, len(records)-1)
+		choice, err = r.readLine()
+		if err != nil || choice == "" || strings.EqualFold(choice, "q") {
+			fmt.Fprintln(r.out) // Add a blank line
+			return
+		}
+	}
+
+	index, err := parseChoice(choice, len(records))
+	if err != nil {
+		fmt.Fprintln(r.errOut, err)
+		fmt.Fprintln(r.out) // Add a blank line
+		return
+	}
+
+	r.viewHistoryEntry(records[index])
+}
+
+// viewHistoryEntry shows a saved chat and offers actions on it until the user goes back
+func (r *repl) viewHistoryEntry(rec *history.Record) {
+	printHistoryEntry(r.out, rec)
+
+	for {
+		fmt.Fprint(r.out, resources.HistoryActions)
+		action, err := r.readLine()
+		if err != nil {
+			return
+		}
+
+		switch strings.ToLower(action) {
+		case "c":
+			r.resumeRecord(rec)
+			return
+
+		case "r":
+			// Re-send to the current model
+			r.send(rec.Prompt())
+			return
+
+		case "m":
+			model, err := selectModel(r.in, r.out, r.cfg.Models, r.model)
+			if err != nil {
+				continue
+			}
+			// Re-send to the selected model, then switch back
+			current := r.model
+			r.model = model
+			r.send(rec.Prompt())
+			r.model = current
+			return
+
+		case "d":
+			fmt.Fprintf(r.out, resources.ConfirmDeleteHistory, rec.ID)
+			answer, err := r.readLine()
+			if err != nil || !isYes(answer) {
+				continue
+			}
+			if err := r.store.Delete(rec.ID); err != nil {
+				fmt.Fprintf(r.errOut, resources.ErrDeleteHistory+"\n", err)
+				continue
+			}
+			fmt.Fprintf(r.out, resources.InfoHistoryDeleted, rec.ID)
+			fmt.Fprintln(r.out) // Add a blank line
+			return
+
+		case "s":
+			defaultName := "response_" + rec.ID + ".md"
+			fmt.Fprintf(r.out, resources.SaveResponsePrompt, defaultName)
+			name, err := r.readLine()
+			if err != nil {
+				continue
+			}
+			if name == "" {
+				name = defaultName
+			}
+			if err := os.WriteFile(name, []byte(rec.Response()+"\n"), 0644); err != nil {
+				fmt.Fprintf(r.errOut, resources.ErrSaveResponse+"\n", err)
+				continue
+			}
+			fmt.Fprintf(r.out, resources.InfoResponseSaved, name)
+
+		case "", "b", "q":
+			fmt.Fprintln(r.out) // Add a blank line
+			return
+
+		default:
+			fmt.Fprintf(r.out, resources.ErrInvalidChoice+"\n", strconv.Quote(action))
+		}
+	}
+}
+
+// printHistoryEntry writes a saved chat with every message after the system prompt
+func printHistoryEntry(w io.Writer, rec *history.Record) {
+	var messages []string
+	for _, msg := range rec.History() {
+		messages = append(messages, fmt.Sprintf(resources.HistoryEntryMessage, history.RoleTitle(msg.Role), msg.Content))
+	}
+	fmt.Fprintf(w, resources.HistoryEntryFormat, rec.ID, rec.Model, strings.Join(messages, "\n"))
+}
+
+// readLine reads a trimmed line of input
+func (r *repl) readLine() (string, error) {
+	if !r.in.Scan() {
+		return "", fmt.Errorf(resources.ErrReadInput)
+	}
+	return strings.TrimSpace(r.in.Text()), nil
+}
+
+// isYes reports whether answer confirms a y/n question
+func isYes(answer string) bool {
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -1,32 +1,98 @@
 package config
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 	"groq-cli-chat/internal/groq"
+	"groq-cli-chat/internal/history"
+	"groq-cli-chat/internal/redact"
 	"groq-cli-chat/resources"
 )
 
 type Config struct {
-	AppTitle      string   `mapstructure:"app_title"`
-	ProviderName  string   `mapstructure:"provider_name"`
-	BaseURL       string   `mapstructure:"base_url"`
-	APIKeyName    string   `mapstructure:"api_key_name"`
-	DefaultModel  string   `mapstructure:"default_model"`
-	Models        []string `mapstructure:"models"`
-	ExcludedModels []string `mapstructure:"excluded_models"`
-	APIKey        string   `mapstructure:"api_key"`
-	ConfigPath    string   // Path to the loaded config file (not stored in YAML)
+	AppTitle          string             `mapstructure:"app_title"`
+	ProviderName      string             `mapstructure:"provider_name"`
+	ProviderType      string             `mapstructure:"provider_type"` // API schema: openai (default), anthropic, gemini or ollama
+	BaseURL           string             `mapstructure:"base_url"`      // May contain {model}, e.g. for Azure OpenAI deployments
+	APIKeyName        string             `mapstructure:"api_key_name"`
+	Auth              groq.Auth          `mapstructure:"auth"`    // How the API key is sent, depends on provider_type by default
+	Headers           map[string]string  `mapstructure:"headers"` // Extra HTTP headers; ${VAR} in values is read from the environment
+	Query             map[string]string  `mapstructure:"query"`   // Extra query parameters, e.g. api-version for Azure OpenAI
+	DefaultModel      string             `mapstructure:"default_model"`
+	Models            []string           `mapstructure:"models"`
+	ExcludedModels    []string           `mapstructure:"excluded_models"`
+	SystemPrompt      string             `mapstructure:"system_prompt"`
+	Personas          map[string]string  `mapstructure:"personas"`
+	Retry             groq.RetryPolicy   `mapstructure:"retry"`
+	Timeout           time.Duration      `mapstructure:"timeout"` // Longest wait for a response to start or continue, 0 means 5m, negative none
+	Parameters        groq.Parameters    `mapstructure:"parameters"`
+	ModelParameters   []ModelParameters  `mapstructure:"model_parameters"`
+	DisableShortcuts  bool               `mapstructure:"disable_shortcuts"`  // Only accept /commands, so e.g. "q" is sent as a prompt
+	HistoryMarkdown   *bool              `mapstructure:"history_markdown"`   // Render history as Markdown files, on by default
+	HistoryRetention  history.Retention  `mapstructure:"history_retention"`  // Pruned at startup, no limits by default
+	HistoryEncryption history.Encryption `mapstructure:"history_encryption"` // Encrypt history at rest, off by default
+	Incognito         bool               `mapstructure:"incognito"`          // Start chats without writing history or sessions
+	Redaction         redact.Settings    `mapstructure:"redaction"`          // Secret detection in prompts and history
+	APIKey            string             `mapstructure:"api_key"`
+	ConfigPath        string             // Path to the loaded config file (not stored in YAML)
+
+	v *viper.Viper // Instance that read the file, so saving keeps the keys SaveConfig doesn't set
+}
+
+// WriteHistoryMarkdown reports whether history records are also saved as Markdown files
+func (c *Config) WriteHistoryMarkdown() bool {
+	return c.HistoryMarkdown == nil || *c.HistoryMarkdown
+}
+
+// RequiresAPIKey reports whether the API key environment variable must be set.
+// Ollama, servers on the local machine, such as llama.cpp, and auth type none need no key;
+// one is still sent when the variable is set.
+func (c *Config) RequiresAPIKey() bool {
+	if c.ProviderType == groq.TypeOllama || c.Auth.Type == groq.AuthNone {
+		return false
+	}
+	u, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return true
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip == nil || !ip.IsLoopback()
+}
+
+// envReference matches ${VAR} in header values; a bare $ is kept as is, since
+// tokens and signatures may contain one
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandHeaders replaces ${VAR} in header values with environment variables,
+// so tokens for gateways need not be stored in the config
+func (c *Config) expandHeaders() {
+	for name, value := range c.Headers {
+		c.Headers[name] = envReference.ReplaceAllStringFunc(value, func(ref string) string {
+			return os.Getenv(envReference.FindStringSubmatch(ref)[1])
+		})
+	}
 }
 
 // Default excluded models - will be moved to config
 var defaultExcludedModels = []string{"whisper", "playai"}
 
+// defaultAPIKeyName is the environment variable holding the key when the config names none
+const defaultAPIKeyName = "GROQ_API_KEY"
+
 func LoadConfig() (*Config, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -49,8 +115,9 @@ func LoadConfig() (*Config, error) {
 			if err != nil {
 				return nil, fmt.Errorf(resources.ErrCreateConfig, err)
 			}
-			fmt.Println(resources.InfoConfigCreated)
+			fmt.Fprintln(os.Stderr, resources.InfoConfigCreated)
 			cfg.ConfigPath = filepath.Join(configDir, "config.yaml")
+			cfg.v = viper.GetViper()
 		} else {
 			return nil, fmt.Errorf(resources.ErrReadConfig, err)
 		}
@@ -59,16 +126,21 @@ func LoadConfig() (*Config, error) {
 			return nil, fmt.Errorf(resources.ErrUnmarshalConfig, err)
 		}
 		cfg.ConfigPath = viper.ConfigFileUsed()
+		cfg.v = viper.GetViper()
 	}
 
 	// Set default API key name if not specified
 	if cfg.APIKeyName == "" {
-		cfg.APIKeyName = "GROQ_API_KEY"
+		cfg.APIKeyName = defaultAPIKeyName
 	}
+	if cfg.BaseURL == "" && cfg.ProviderType == groq.TypeOllama {
+		cfg.BaseURL = resources.DefaultOllamaURL
+	}
+	cfg.expandHeaders()
 
 	// Load API key from environment using the configured name
 	cfg.APIKey = os.Getenv(cfg.APIKeyName)
-	if cfg.APIKey == "" {
+	if cfg.APIKey == "" && cfg.RequiresAPIKey() {
 		return nil, fmt.Errorf(resources.ErrNoAPIKey+": %s", cfg.APIKeyName)
 	}
 
@@ -98,19 +170,25 @@ func ensureConfigDir(configDir string) error {
 }
 
 func createDefaultConfig(configDir string) (*Config, error) {
-	client, err := groq.NewClient(resources.DefaultBaseURL, os.Getenv("GROQ_API_KEY"))
+	// The models are listed with the key, so fail clearly rather than with a 401
+	apiKey := os.Getenv(defaultAPIKeyName)
+	if apiKey == "" {
+		return nil, fmt.Errorf(resources.ErrNoAPIKey+": %s", defaultAPIKeyName)
+	}
+
+	client, err := groq.NewClient(resources.DefaultBaseURL, apiKey)
 	if err != nil {
 		return nil, fmt.Errorf(resources.ErrCreateClient, err)
 	}
 
-	allModels, err := client.ListModels()
+	allModels, err := client.ListModels(context.Background())
 	if err != nil {
 		return nil, fmt.Errorf(resources.ErrListModels, err)
 	}
-	
+
 	// Use the default excluded models for initial config
 	excludedModelsList := defaultExcludedModels
-	
+
 	// Filter out excluded models
 	var filteredModels []string
 	for _, model := range allModels {
@@ -125,7 +203,7 @@ func createDefaultConfig(configDir string) (*Config, error) {
 			filteredModels = append(filteredModels, model)
 		}
 	}
-	
+
 	// Sort models alphabetically
 	sort.Strings(filteredModels)
 
@@ -135,16 +213,16 @@ func createDefaultConfig(configDir string) (*Config, error) {
 	}
 
 	configPath := filepath.Join(configDir, "config.yaml")
-	
+
 	cfg := &Config{
-		AppTitle:      "🍎 One-shot Groq CLI chat",
-		ProviderName:  "Groq",
-		BaseURL:       resources.DefaultBaseURL,
-		APIKeyName:    "GROQ_API_KEY",
-		DefaultModel:  filteredModels[0],
-		Models:        filteredModels,
+		AppTitle:       "🍎 One-shot Groq CLI chat",
+		ProviderName:   "Groq",
+		BaseURL:        resources.DefaultBaseURL,
+		APIKeyName:     defaultAPIKeyName,
+		DefaultModel:   filteredModels[0],
+		Models:         filteredModels,
 		ExcludedModels: excludedModelsList,
-		ConfigPath:    configPath,
+		ConfigPath:     configPath,
 	}
 
 	viper.Set("app_title", cfg.AppTitle)
@@ -164,17 +242,30 @@ func createDefaultConfig(configDir string) (*Config, error) {
 
 // SaveConfig saves the configuration to the specified path
 func SaveConfig(cfg *Config, configPath string) error {
-	// Set the values in viper
-	viper.Set("app_title", cfg.AppTitle)
-	viper.Set("provider_name", cfg.ProviderName)
-	viper.Set("base_url", cfg.BaseURL)
-	viper.Set("api_key_name", cfg.APIKeyName)
-	viper.Set("excluded_models", cfg.ExcludedModels)
-	viper.Set("default_model", cfg.DefaultModel)
-	viper.Set("models", cfg.Models)
+	// Set the values in the viper instance that read the file, so all other keys are kept
+	v := cfg.v
+	if v == nil {
+		v = viper.GetViper()
+	}
+	v.Set("app_title", cfg.AppTitle)
+	v.Set("provider_name", cfg.ProviderName)
+	v.Set("base_url", cfg.BaseURL)
+	v.Set("api_key_name", cfg.APIKeyName)
+	v.Set("excluded_models", cfg.ExcludedModels)
+	v.Set("default_model", cfg.DefaultModel)
+	v.Set("models", cfg.Models)
+	if cfg.ProviderType != "" {
+		v.Set("provider_type", cfg.ProviderType)
+	}
+	if cfg.SystemPrompt != "" {
+		v.Set("system_prompt", cfg.SystemPrompt)
+	}
+	if len(cfg.Personas) > 0 {
+		v.Set("personas", cfg.Personas)
+	}
 
 	// Write the config to file
-	if err := viper.WriteConfigAs(configPath); err != nil {
+	if err := v.WriteConfigAs(configPath); err != nil {
 		return fmt.Errorf(resources.ErrWriteConfig, err)
 	}
 
@@ -185,44 +276,49 @@ func SaveConfig(cfg *Config, configPath string) error {
 func LoadSpecificConfig(configPath string) (*Config, error) {
 	v := viper.New()
 	v.SetConfigFile(configPath)
-	
+
 	cfg := &Config{}
 	if err := v.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("failed to read config file: %v", err)
 	}
-	
+
 	if err := v.Unmarshal(cfg); err != nil {
 		return nil, fmt.Errorf(resources.ErrUnmarshalConfig, err)
 	}
-	
+
 	// Set the config path
 	cfg.ConfigPath = configPath
-	
+	cfg.v = v
+
 	// Set default API key name if not specified
 	if cfg.APIKeyName == "" {
-		cfg.APIKeyName = "GROQ_API_KEY"
+		cfg.APIKeyName = defaultAPIKeyName
+	}
+	if cfg.BaseURL == "" && cfg.ProviderType == groq.TypeOllama {
+		cfg.BaseURL = resources.DefaultOllamaURL
 	}
-	
+	cfg.expandHeaders()
+
 	// Load API key from environment using the configured name
 	cfg.APIKey = os.Getenv(cfg.APIKeyName)
-	if cfg.APIKey == "" {
+	if cfg.APIKey == "" && cfg.RequiresAPIKey() {
 		return nil, fmt.Errorf(resources.ErrNoAPIKey+": %s", cfg.APIKeyName)
 	}
-	
+
 	// Validate models
 	if err := ValidateModels(cfg.Models); err != nil {
 		return nil, fmt.Errorf(resources.ErrInvalidConfig, err)
 	}
-	
+
 	// Validate default model
 	if cfg.DefaultModel != "" && !IsValidModel(cfg.DefaultModel, cfg.Models) {
 		return nil, fmt.Errorf(resources.ErrInvalidDefaultModel, cfg.DefaultModel)
 	}
-	
+
 	// Set default excluded models if not specified
 	if len(cfg.ExcludedModels) == 0 {
 		cfg.ExcludedModels = defaultExcludedModels
 	}
-	
+
 	return cfg, nil
 }
@@ -0,0 +1,21 @@
+package config
+
+import "groq-cli-chat/internal/groq"
+
+// ModelParameters overrides the global generation parameters for one model.
+// It is a list entry rather than a map since model names may contain dots.
+type ModelParameters struct {
+	Model           string `mapstructure:"model"`
+	groq.Parameters `mapstructure:",squash"`
+}
+
+// ParametersFor returns the global parameters merged with the overrides of model
+func (c *Config) ParametersFor(model string) groq.Parameters {
+	params := c.Parameters
+	for _, override := range c.ModelParameters {
+		if override.Model == model {
+			params = params.Merge(override.Parameters)
+		}
+	}
+	return params
+}
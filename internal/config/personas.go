@@ -0,0 +1,48 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// PersonaNames returns the names of the configured personas in alphabetical order
+func (c *Config) PersonaNames() []string {
+	names := make([]string, 0, len(c.Personas))
+	for name := range c.Personas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Persona returns the name of a persona as configured and its system prompt.
+// Names are compared ignoring case, since viper lowercases the keys read from
+// the config file. An empty name selects the default system prompt.
+func (c *Config) Persona(name string) (configured, prompt string, ok bool) {
+	if name == "" {
+		return "", c.SystemPrompt, true
+	}
+	if prompt, ok := c.Personas[name]; ok {
+		return name, prompt, true
+	}
+	for configured, prompt := range c.Personas {
+		if strings.EqualFold(configured, name) {
+			return configured, prompt, true
+		}
+	}
+	return "", "", false
+}
+
+// PersonaFor returns the name of the persona using systemPrompt, "" for the
+// default system prompt. ok is false if no persona uses it.
+func (c *Config) PersonaFor(systemPrompt string) (name string, ok bool) {
+	if systemPrompt == c.SystemPrompt {
+		return "", true
+	}
+	for _, name := range c.PersonaNames() {
+		if c.Personas[name] == systemPrompt {
+			return name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,319 @@
+package groq
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"groq-cli-chat/resources"
+)
+
+// anthropicVersion is sent in the anthropic-version header of every request
+const anthropicVersion = "2023-06-01"
+
+// anthropicMaxTokens is used when max_tokens is not set, since the Messages API requires it
+const anthropicMaxTokens = 4096
+
+// anthropicProvider is the Provider for the Anthropic Messages API.
+// Seed and the frequency and presence penalties are not supported by the API and are not sent.
+type anthropicProvider struct {
+	client *Client
+}
+
+var _ Provider = (*anthropicProvider)(nil)
+
+// newAnthropicProvider uses client for transport, authenticating with the
+// x-api-key header unless another auth is configured
+func newAnthropicProvider(client *Client) *anthropicProvider {
+	if client.auth.Type == "" {
+		client.auth = Auth{Type: AuthHeader, Name: "x-api-key"}
+	}
+	client.setDefaultHeader("anthropic-version", anthropicVersion)
+	return &anthropicProvider{client: client}
+}
+
+// anthropicRequest is the payload of a Messages API request
+type anthropicRequest struct {
+	Model         string             `json:"model"`
+	System        string             `json:"system,omitempty"`
+	Messages      []anthropicMessage `json:"messages"`
+	MaxTokens     int                `json:"max_tokens"`
+	Temperature   *float64           `json:"temperature,omitempty"`
+	TopP          *float64           `json:"top_p,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+	Stream        bool               `json:"stream,omitempty"`
+}
+
+// anthropicMessage is a message made of content blocks
+type anthropicMessage struct {
+	Role    string                  `json:"role"`
+	Content []anthropicContentBlock `json:"content"`
+}
+
+// anthropicContentBlock is a content block; only text blocks are sent and read
+type anthropicContentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// anthropicResponse is the response of a Messages API request
+type anthropicResponse struct {
+	ID      string                  `json:"id"`
+	Content []anthropicContentBlock `json:"content"`
+	Usage   anthropicUsage          `json:"usage"`
+}
+
+// anthropicUsage holds token counts. Cached prompt tokens are reported apart
+// from input_tokens and are added to the prompt tokens of our stats.
+type anthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+// anthropicEvent is a single server-sent event of a streamed response
+type anthropicEvent struct {
+	Type    string             `json:"type"`
+	Message *anthropicResponse `json:"message"` // message_start
+	Delta   struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"` // content_block_delta and message_delta
+	Usage *anthropicUsage `json:"usage"` // message_delta, cumulative
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// anthropicModel is an entry of the models endpoint
+type anthropicModel struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (u anthropicUsage) usage() Usage {
+	prompt := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	return Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: u.OutputTokens,
+		TotalTokens:      prompt + u.OutputTokens,
+	}
+}
+
+// newAnthropicRequest moves system messages to the system field and sends
+// the other messages as text content blocks
+func newAnthropicRequest(model string, messages []Message, params Parameters, stream bool) anthropicRequest {
+	req := anthropicRequest{
+		Model:         model,
+		MaxTokens:     anthropicMaxTokens,
+		Temperature:   params.Temperature,
+		TopP:          params.TopP,
+		StopSequences: params.Stop,
+		Stream:        stream,
+	}
+	if params.MaxTokens != nil {
+		req.MaxTokens = *params.MaxTokens
+	}
+
+	var system []string
+	for _, msg := range messages {
+		if msg.Role == RoleSystem {
+			system = append(system, msg.Content)
+			continue
+		}
+		req.Messages = append(req.Messages, anthropicMessage{
+			Role:    msg.Role,
+			Content: []anthropicContentBlock{{Type: "text", Text: msg.Content}},
+		})
+	}
+	req.System = strings.Join(system, "\n\n")
+
+	return req
+}
+
+func (p *anthropicProvider) ListModels(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+
+	var models []string
+	endpoint := "models?limit=1000"
+	for {
+		resp, err := p.client.makeRequest(ctx, "GET", endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Data    []anthropicModel `json:"data"`
+			HasMore bool             `json:"has_more"`
+			LastID  string           `json:"last_id"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf(resources.ErrDecodeResponse, err)
+		}
+
+		for _, model := range page.Data {
+			models = append(models, model.ID)
+		}
+		if !page.HasMore || page.LastID == "" {
+			return models, nil
+		}
+		endpoint = "models?limit=1000&after_id=" + url.QueryEscape(page.LastID)
+	}
+}
+
+func (p *anthropicProvider) GetModel(ctx context.Context, model string) (*ModelInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+
+	resp, err := p.client.makeRequest(ctx, "GET", "models/"+url.PathEscape(model), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var info anthropicModel
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf(resources.ErrDecodeResponse, err)
+	}
+
+	// The API does not report context windows
+	return &ModelInfo{
+		ID:      info.ID,
+		Object:  info.Type,
+		Created: info.CreatedAt.Unix(),
+		OwnedBy: "anthropic",
+		Active:  true,
+	}, nil
+}
+
+func (p *anthropicProvider) Chat(ctx context.Context, model string, messages []Message, params Parameters) (*ChatResponse, error) {
+	// Start timing the request
+	startTime := time.Now()
+
+	body, err := json.Marshal(newAnthropicRequest(model, messages, params, false))
+	if err != nil {
+		return nil, fmt.Errorf(resources.ErrEncodePayload, err)
+	}
+
+	resp, err := p.client.makeRequest(ctx, "POST", "messages", body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf(resources.ErrDecodeResponse, err)
+	}
+
+	var content strings.Builder
+	for _, block := range result.Content {
+		if block.Type == "text" {
+			content.WriteString(block.Text)
+		}
+	}
+	if content.Len() == 0 {
+		return nil, fmt.Errorf(resources.ErrEmptyResponse)
+	}
+
+	chatResp := &ChatResponse{
+		ID:      result.ID,
+		Choices: []Choice{{Message: Message{Role: RoleAssistant, Content: content.String()}}},
+		Usage:   result.Usage.usage(),
+	}
+	chatResp.Usage.fillTimes(time.Since(startTime).Seconds())
+
+	return chatResp, nil
+}
+
+func (p *anthropicProvider) ChatStream(ctx context.Context, model string, messages []Message, params Parameters, onDelta func(string)) (*ChatResponse, error) {
+	// Start timing the request
+	startTime := time.Now()
+
+	body, err := json.Marshal(newAnthropicRequest(model, messages, params, true))
+	if err != nil {
+		return nil, fmt.Errorf(resources.ErrEncodePayload, err)
+	}
+
+	resp, err := p.client.makeRequest(ctx, "POST", "messages", body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	var usage anthropicUsage
+	var id string
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			// The event name is repeated in the data, so event: lines are skipped too
+			continue
+		}
+
+		var event anthropicEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &event); err != nil {
+			return nil, fmt.Errorf(resources.ErrDecodeResponse, err)
+		}
+
+		switch event.Type {
+		case "message_start":
+			if event.Message != nil {
+				id = event.Message.ID
+				usage = event.Message.Usage
+			}
+		case "content_block_delta":
+			// Thinking and tool input deltas are not part of the answer
+			if event.Delta.Type != "text_delta" || event.Delta.Text == "" {
+				continue
+			}
+			content.WriteString(event.Delta.Text)
+			if onDelta != nil {
+				onDelta(event.Delta.Text)
+			}
+		case "message_delta":
+			if event.Usage != nil {
+				usage.OutputTokens = event.Usage.OutputTokens
+				if event.Usage.InputTokens > 0 {
+					usage.InputTokens = event.Usage.InputTokens
+				}
+			}
+		case "error":
+			if event.Error != nil {
+				return nil, fmt.Errorf(resources.ErrStreamEvent, event.Error.Type, event.Error.Message)
+			}
+		}
+		if event.Type == "message_stop" {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf(resources.ErrReadStream, err)
+	}
+
+	if content.Len() == 0 {
+		return nil, fmt.Errorf(resources.ErrEmptyResponse)
+	}
+
+	chatResp := &ChatResponse{
+		ID:      id,
+		Choices: []Choice{{Message: Message{Role: RoleAssistant, Content: content.String()}}},
+		Usage:   usage.usage(),
+	}
+	chatResp.Usage.fillTimes(time.Since(startTime).Seconds())
+
+	return chatResp, nil
+}
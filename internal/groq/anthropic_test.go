@@ -0,0 +1,173 @@
+package groq
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// anthropicServer returns an Anthropic provider for a server answering
+// /messages with body. The decoded request is stored in req, if not nil.
+func anthropicServer(t *testing.T, body string, req *anthropicRequest) Provider {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/messages" {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("x-api-key"); got != "test-key" {
+			t.Errorf("x-api-key = %q, want the API key", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Errorf("Authorization = %q, want none", got)
+		}
+		if got := r.Header.Get("anthropic-version"); got != anthropicVersion {
+			t.Errorf("anthropic-version = %q, want %s", got, anthropicVersion)
+		}
+		if req != nil {
+			if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+				t.Errorf("decode request: %v", err)
+			}
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	provider, err := NewProvider(Options{Type: TypeAnthropic, BaseURL: server.URL, APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+	return provider
+}
+
+var anthropicMessages = []Message{
+	{Role: RoleSystem, Content: "Be brief."},
+	{Role: RoleUser, Content: "hi"},
+	{Role: RoleAssistant, Content: "hello"},
+	{Role: RoleUser, Content: "again"},
+}
+
+func TestAnthropicChat(t *testing.T) {
+	body := `{"id":"msg_1","type":"message","role":"assistant",
+		"content":[{"type":"text","text":"Hi "},{"type":"tool_use","text":""},{"type":"text","text":"there"}],
+		"usage":{"input_tokens":10,"output_tokens":3,"cache_read_input_tokens":5}}`
+	var req anthropicRequest
+	provider := anthropicServer(t, body, &req)
+
+	temperature := 0.5
+	resp, err := provider.Chat(context.Background(), "claude-test", anthropicMessages, Parameters{Temperature: &temperature})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	// The system prompt is moved out of the messages
+	if req.System != "Be brief." {
+		t.Errorf("system = %q, want the system prompt", req.System)
+	}
+	if len(req.Messages) != 3 || req.Messages[0].Role != RoleUser || req.Messages[1].Role != RoleAssistant {
+		t.Errorf("messages = %+v, want user, assistant, user", req.Messages)
+	}
+	if block := req.Messages[2].Content[0]; block.Type != "text" || block.Text != "again" {
+		t.Errorf("last message = %+v, want a text block", block)
+	}
+	// max_tokens is required by the API
+	if req.MaxTokens != anthropicMaxTokens {
+		t.Errorf("max_tokens = %d, want the default %d", req.MaxTokens, anthropicMaxTokens)
+	}
+	if req.Model != "claude-test" || req.Stream || req.Temperature == nil || *req.Temperature != 0.5 {
+		t.Errorf("request = %+v, want model, temperature and no stream", req)
+	}
+
+	if got := resp.Choices[0].Message.Content; got != "Hi there" {
+		t.Errorf("content = %q, want the text blocks joined", got)
+	}
+	// Cached prompt tokens count as prompt tokens
+	if resp.Usage.PromptTokens != 15 || resp.Usage.CompletionTokens != 3 || resp.Usage.TotalTokens != 18 {
+		t.Errorf("usage = %+v, want 15 + 3 tokens", resp.Usage)
+	}
+	if resp.ID != "msg_1" {
+		t.Errorf("ID = %q, want msg_1", resp.ID)
+	}
+}
+
+func TestAnthropicMaxTokens(t *testing.T) {
+	var req anthropicRequest
+	provider := anthropicServer(t, `{"id":"msg_2","content":[{"type":"text","text":"ok"}]}`, &req)
+
+	maxTokens := 100
+	if _, err := provider.Chat(context.Background(), "claude-test", anthropicMessages[1:], Parameters{MaxTokens: &maxTokens}); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if req.MaxTokens != 100 || req.System != "" {
+		t.Errorf("max_tokens = %d, system = %q; want 100 and no system prompt", req.MaxTokens, req.System)
+	}
+}
+
+func TestAnthropicChatStream(t *testing.T) {
+	body := `event: message_start
+data: {"type":"message_start","message":{"id":"msg_3","content":[],"usage":{"input_tokens":12,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: ping
+data: {"type":"ping"}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"hmm"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hel"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"lo"}}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":4}}
+
+event: message_stop
+data: {"type":"message_stop"}
+`
+	var req anthropicRequest
+	provider := anthropicServer(t, body, &req)
+
+	var deltas []string
+	resp, err := provider.ChatStream(context.Background(), "claude-test", anthropicMessages, Parameters{}, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+
+	if !req.Stream {
+		t.Error("request is not streamed")
+	}
+	if strings.Join(deltas, "|") != "Hel|lo" {
+		t.Errorf("deltas = %q, want the text deltas only", deltas)
+	}
+	if got := resp.Choices[0].Message.Content; got != "Hello" {
+		t.Errorf("content = %q, want Hello", got)
+	}
+	// Input tokens come from message_start, output tokens from the cumulative message_delta
+	if resp.Usage.PromptTokens != 12 || resp.Usage.CompletionTokens != 4 || resp.ID != "msg_3" {
+		t.Errorf("ID %q, usage %+v; want msg_3 with 12 + 4 tokens", resp.ID, resp.Usage)
+	}
+}
+
+func TestAnthropicChatStreamError(t *testing.T) {
+	body := `event: message_start
+data: {"type":"message_start","message":{"id":"msg_4","content":[],"usage":{"input_tokens":1}}}
+
+event: error
+data: {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}
+`
+	provider := anthropicServer(t, body, nil)
+
+	_, err := provider.ChatStream(context.Background(), "claude-test", anthropicMessages, Parameters{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "overloaded_error") || !strings.Contains(err.Error(), "Overloaded") {
+		t.Errorf("error = %v, want the overloaded stream error", err)
+	}
+}
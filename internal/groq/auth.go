@@ -0,0 +1,58 @@
+package groq
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"groq-cli-chat/resources"
+)
+
+// Ways of sending the API key, selected with auth.type in the config
+const (
+	AuthBearer = "bearer" // Authorization: Bearer <key>, the default for OpenAI-compatible APIs
+	AuthHeader = "header" // The key as the value of the header Name, e.g. api-key for Azure OpenAI
+	AuthQuery  = "query"  // The key as the query parameter Name
+	AuthNone   = "none"   // No key is sent
+)
+
+// AuthTypes lists the supported auth types
+var AuthTypes = []string{AuthBearer, AuthHeader, AuthQuery, AuthNone}
+
+// Auth configures how the API key is sent with every request.
+// A zero Auth uses the default of the provider type.
+type Auth struct {
+	Type string `mapstructure:"type"` // One of AuthTypes
+	Name string `mapstructure:"name"` // Header or query parameter name, required for header and query
+}
+
+// Validate reports an unknown type or a missing name
+func (a Auth) Validate() error {
+	switch a.Type {
+	case "", AuthBearer, AuthNone:
+		return nil
+	case AuthHeader, AuthQuery:
+		if a.Name == "" {
+			return fmt.Errorf(resources.ErrAuthNoName, a.Type)
+		}
+		return nil
+	}
+	return fmt.Errorf(resources.ErrUnknownAuthType, a.Type, strings.Join(AuthTypes, ", "))
+}
+
+// apply adds apiKey to req. An empty key is never sent.
+func (a Auth) apply(req *http.Request, apiKey string) {
+	if apiKey == "" {
+		return
+	}
+	switch a.Type {
+	case "", AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	case AuthHeader:
+		req.Header.Set(a.Name, apiKey)
+	case AuthQuery:
+		query := req.URL.Query()
+		query.Set(a.Name, apiKey)
+		req.URL.RawQuery = query.Encode()
+	}
+}
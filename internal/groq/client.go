@@ -2,34 +2,154 @@ package groq
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
+	"path"
+	"strings"
 	"time"
 
 	"groq-cli-chat/resources"
 )
 
+// metadataTimeout limits model listing and lookups. Chat requests have no
+// fixed timeout since long answers can take minutes; cancel their context instead.
+const metadataTimeout = 30 * time.Second
+
+// DefaultTimeout is the idle timeout used unless SetTimeout says otherwise
+const DefaultTimeout = 5 * time.Minute
+
+// errIdle is the cause of requests cancelled by the idle timeout
+var errIdle = errors.New("idle timeout")
+
+// modelPlaceholder in the base URL is replaced by the model of a chat request,
+// for deployment-style URLs such as Azure OpenAI's .../openai/deployments/{model}
+const modelPlaceholder = "{model}"
+
+// Client is the Provider for OpenAI-compatible chat completion APIs such as Groq
 type Client struct {
 	baseURL    string
 	apiKey     string
+	retry      RetryPolicy
+	onRetry    RetryNotifier
+	auth       Auth
+	headers    map[string]string // Sent with every request
+	query      map[string]string // Added to the URL of every request
+	timeout    time.Duration     // Idle timeout, 0 for none
 	httpClient *http.Client
 }
 
+var _ Provider = (*Client)(nil)
+
+// NewClient creates a client for baseURL, which may contain a {model} placeholder.
+// The key is sent as a bearer token unless SetAuth says otherwise; an empty
+// apiKey is not sent, for local servers such as llama.cpp.
 func NewClient(baseURL, apiKey string) (*Client, error) {
-	if baseURL == "" || apiKey == "" {
+	if baseURL == "" {
 		return nil, fmt.Errorf(resources.ErrInvalidClientParams)
 	}
 	return &Client{
 		baseURL:    baseURL,
 		apiKey:     apiKey,
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+		retry:      DefaultRetryPolicy(),
+		timeout:    DefaultTimeout,
+		httpClient: &http.Client{},
 	}, nil
 }
 
-func (c *Client) ListModels() ([]string, error) {
-	resp, err := c.makeRequest("GET", "models", nil)
+// SetAuth sets how the API key is sent
+func (c *Client) SetAuth(auth Auth) error {
+	if err := auth.Validate(); err != nil {
+		return err
+	}
+	c.auth = auth
+	return nil
+}
+
+// SetHeaders sets extra headers sent with every request, e.g. HTTP-Referer for OpenRouter
+func (c *Client) SetHeaders(headers map[string]string) {
+	c.headers = headers
+}
+
+// SetTimeout sets how long a request may wait for the response to start, and a
+// response for more data, before it fails. Zero uses DefaultTimeout, a negative
+// value disables the timeout. Long streamed answers are not cut off as long as
+// data keeps arriving.
+func (c *Client) SetTimeout(timeout time.Duration) {
+	switch {
+	case timeout == 0:
+		c.timeout = DefaultTimeout
+	case timeout < 0:
+		c.timeout = 0
+	default:
+		c.timeout = timeout
+	}
+}
+
+// SetQuery sets extra query parameters sent with every request, e.g. api-version for Azure OpenAI
+func (c *Client) SetQuery(query map[string]string) {
+	c.query = query
+}
+
+// setDefaultHeader sets a header unless SetHeaders already did
+func (c *Client) setDefaultHeader(name, value string) {
+	for key := range c.headers {
+		if strings.EqualFold(key, name) {
+			return
+		}
+	}
+	headers := map[string]string{name: value}
+	for key, value := range c.headers {
+		headers[key] = value
+	}
+	c.headers = headers
+}
+
+// baseURLFor returns the base URL of a request for model. Requests not bound to a
+// model, such as listing models, drop the placeholder and the path segment before it,
+// so .../openai/deployments/{model} lists models at .../openai/models.
+func (c *Client) baseURLFor(model string) string {
+	i := strings.Index(c.baseURL, modelPlaceholder)
+	if i < 0 {
+		return c.baseURL
+	}
+	if model != "" {
+		return strings.ReplaceAll(c.baseURL, modelPlaceholder, neturl.PathEscape(model))
+	}
+
+	u, err := neturl.Parse(strings.TrimSuffix(c.baseURL[:i], "/"))
+	if err != nil {
+		return c.baseURL[:i]
+	}
+	// With {model} right after the host there is no segment left to drop
+	dir := path.Dir(u.Path)
+	if dir == "." || dir == "/" {
+		dir = ""
+	}
+	u.Path = dir
+	return u.String()
+}
+
+// SetRetryPolicy sets how requests failing with 429 or 5xx are retried.
+// Unset fields keep their default values.
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	c.retry = policy.withDefaults()
+}
+
+// SetRetryNotifier sets a callback invoked before every retry
+func (c *Client) SetRetryNotifier(notify RetryNotifier) {
+	c.onRetry = notify
+}
+
+func (c *Client) ListModels(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+
+	resp, err := c.makeRequest(ctx, "GET", "models", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -51,9 +171,12 @@ func (c *Client) ListModels() ([]string, error) {
 	return models, nil
 }
 
-func (c *Client) GetModel(model string) (*ModelInfo, error) {
+func (c *Client) GetModel(ctx context.Context, model string) (*ModelInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+
 	endpoint := fmt.Sprintf("models/%s", model)
-	resp, err := c.makeRequest("GET", endpoint, nil)
+	resp, err := c.makeRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -67,21 +190,23 @@ func (c *Client) GetModel(model string) (*ModelInfo, error) {
 	return &modelInfo, nil
 }
 
-// Chat sends a chat request to the Groq API
-func (c *Client) Chat(model, message string) (*ChatResponse, error) {
+// Chat sends a chat request to the Groq API.
+// The messages slice holds the whole conversation, oldest message first.
+func (c *Client) Chat(ctx context.Context, model string, messages []Message, params Parameters) (*ChatResponse, error) {
 	// Start timing the request
 	startTime := time.Now()
 
-	payload := map[string]interface{}{
-		"model":    model,
-		"messages": []map[string]string{{"role": "user", "content": message}},
+	payload := ChatRequest{
+		Model:      model,
+		Messages:   messages,
+		Parameters: params,
 	}
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return nil, fmt.Errorf(resources.ErrEncodePayload, err)
 	}
 
-	resp, err := c.makeRequest("POST", "chat/completions", bytes.NewReader(body))
+	resp, err := c.makeModelRequest(ctx, model, "POST", "chat/completions", body)
 	if err != nil {
 		return nil, err
 	}
@@ -90,7 +215,7 @@ func (c *Client) Chat(model, message string) (*ChatResponse, error) {
 	// Read the entire response body
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
+		return nil, fmt.Errorf(resources.ErrReadResponse, err)
 	}
 
 	// Debug print
@@ -103,36 +228,155 @@ func (c *Client) Chat(model, message string) (*ChatResponse, error) {
 
 	// Verify that we have a valid response with content
 	if len(chatResp.Choices) == 0 || chatResp.Choices[0].Message.Content == "" {
-		return nil, fmt.Errorf("received empty response from API")
+		return nil, fmt.Errorf(resources.ErrEmptyResponse)
 	}
 
 	// Calculate elapsed time if not provided by the API
-	if chatResp.Usage.CompletionTime <= 0 {
-		chatResp.Usage.CompletionTime = time.Since(startTime).Seconds()
+	var extra struct {
+		Timings *llamaTimings `json:"timings"`
 	}
+	if json.Unmarshal(bodyBytes, &extra) == nil {
+		extra.Timings.apply(&chatResp.Usage)
+	}
+	chatResp.Usage.fillTimes(time.Since(startTime).Seconds())
 
 	return &chatResp, nil
 }
 
-func (c *Client) makeRequest(method, endpoint string, body io.Reader) (*http.Response, error) {
-	url := fmt.Sprintf("%s/%s", c.baseURL, endpoint)
-	req, err := http.NewRequest(method, url, body)
+// makeRequest sends a request not bound to a model
+func (c *Client) makeRequest(ctx context.Context, method, endpoint string, body []byte) (*http.Response, error) {
+	return c.makeModelRequest(ctx, "", method, endpoint, body)
+}
+
+// makeModelRequest sends a request and retries it according to the retry policy.
+// Any status other than 200 is returned as an *APIError.
+func (c *Client) makeModelRequest(ctx context.Context, model, method, endpoint string, body []byte) (*http.Response, error) {
+	url := fmt.Sprintf("%s/%s", c.baseURLFor(model), endpoint)
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.doRequest(ctx, method, url, body)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		apiErr := newAPIError(resp, bodyBytes)
+
+		if attempt >= c.retry.MaxAttempts || !isRetryable(resp.StatusCode) {
+			return nil, apiErr
+		}
+		delay, ok := c.retry.delay(attempt, resp.Header)
+		if !ok {
+			return nil, apiErr
+		}
+
+		if c.onRetry != nil {
+			c.onRetry(attempt, c.retry.MaxAttempts, delay, resp.StatusCode)
+		}
+
+		// Wait before the next attempt unless the request is cancelled
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf(resources.ErrHTTP, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// doRequest sends a single HTTP request. The request is cancelled when the
+// response doesn't start, or its body stalls, for longer than the idle timeout.
+func (c *Client) doRequest(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	if c.timeout <= 0 {
+		return c.send(ctx, method, url, body)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	timer := time.AfterFunc(c.timeout, func() { cancel(errIdle) })
+	resp, err := c.send(ctx, method, url, body)
+	if err != nil {
+		timer.Stop()
+		cancel(nil)
+		if context.Cause(ctx) == errIdle {
+			return nil, fmt.Errorf(resources.ErrIdleTimeout, c.timeout)
+		}
+		return nil, err
+	}
+	timer.Reset(c.timeout)
+	resp.Body = &idleBody{ReadCloser: resp.Body, ctx: ctx, cancel: cancel, timer: timer, timeout: c.timeout}
+	return resp, nil
+}
+
+// idleBody restarts the idle timer whenever data arrives and reports a stalled
+// body as a timeout rather than a cancellation
+type idleBody struct {
+	io.ReadCloser
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func (b *idleBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.timer.Reset(b.timeout)
+	}
+	if err != nil && err != io.EOF && context.Cause(b.ctx) == errIdle {
+		err = fmt.Errorf(resources.ErrIdleTimeout, b.timeout)
+	}
+	return n, err
+}
+
+func (b *idleBody) Close() error {
+	b.timer.Stop()
+	b.cancel(nil)
+	return b.ReadCloser.Close()
+}
+
+// send sends a single HTTP request without a timeout of its own
+func (c *Client) send(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
 	if err != nil {
 		return nil, fmt.Errorf(resources.ErrCreateRequest, err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	req.Header.Set("Content-Type", "application/json")
+	for name, value := range c.headers {
+		// Skip headers read from unset environment variables
+		if value != "" {
+			req.Header.Set(name, value)
+		}
+	}
+	if len(c.query) > 0 {
+		query := req.URL.Query()
+		for name, value := range c.query {
+			query.Set(name, value)
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+	c.auth.apply(req, c.apiKey)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		// Report the URL without the query parameters added here, which may hold the key
+		var urlErr *neturl.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = url
+		}
 		return nil, fmt.Errorf(resources.ErrHTTP, err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf(resources.ErrAPI, resp.StatusCode, string(bodyBytes))
-	}
-
 	return resp, nil
 }
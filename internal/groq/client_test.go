@@ -0,0 +1,122 @@
+package groq
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBaseURLFor(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		model   string
+		want    string
+	}{
+		{"https://api.groq.com/openai/v1", "llama", "https://api.groq.com/openai/v1"},
+		{"https://api.groq.com/openai/v1", "", "https://api.groq.com/openai/v1"},
+		{"https://x.openai.azure.com/openai/deployments/{model}", "gpt-4o", "https://x.openai.azure.com/openai/deployments/gpt-4o"},
+		{"https://x.openai.azure.com/openai/deployments/{model}", "a/b c", "https://x.openai.azure.com/openai/deployments/a%2Fb%20c"},
+		// Without a model the placeholder and the segment before it are dropped
+		{"https://x.openai.azure.com/openai/deployments/{model}", "", "https://x.openai.azure.com/openai"},
+		{"https://x.openai.azure.com/openai/deployments/{model}/", "", "https://x.openai.azure.com/openai"},
+		{"https://host/v1/{model}", "", "https://host"},
+		// With nothing before the placeholder the host is left
+		{"https://host/{model}", "", "https://host"},
+		{"https://host/{model}", "m", "https://host/m"},
+		{"http://localhost:8080/{model}/v1", "", "http://localhost:8080"},
+	}
+	for _, tt := range tests {
+		client, err := NewClient(tt.baseURL, "")
+		if err != nil {
+			t.Fatalf("NewClient: %v", err)
+		}
+		if got := client.baseURLFor(tt.model); got != tt.want {
+			t.Errorf("baseURLFor(%q) with %s = %s, want %s", tt.model, tt.baseURL, got, tt.want)
+		}
+	}
+}
+
+func TestAuthApply(t *testing.T) {
+	tests := []struct {
+		name   string
+		auth   Auth
+		apiKey string
+		header string // Header expected to hold the key, "" for none
+		want   string // Its value
+		query  string // Query parameter expected to hold the key, "" for none
+	}{
+		{name: "default", auth: Auth{}, apiKey: "k", header: "Authorization", want: "Bearer k"},
+		{name: "bearer", auth: Auth{Type: AuthBearer}, apiKey: "k", header: "Authorization", want: "Bearer k"},
+		{name: "header", auth: Auth{Type: AuthHeader, Name: "api-key"}, apiKey: "k", header: "Api-Key", want: "k"},
+		{name: "query", auth: Auth{Type: AuthQuery, Name: "key"}, apiKey: "k", query: "key"},
+		{name: "none", auth: Auth{Type: AuthNone}, apiKey: "k"},
+		{name: "empty key", auth: Auth{}, apiKey: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "https://host/v1/models?api-version=1", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.auth.apply(req, tt.apiKey)
+
+			if tt.header != "" {
+				if got := req.Header.Get(tt.header); got != tt.want {
+					t.Errorf("%s = %q, want %q", tt.header, got, tt.want)
+				}
+			} else if len(req.Header) != 0 {
+				t.Errorf("headers = %v, want none", req.Header)
+			}
+
+			query := req.URL.Query()
+			if query.Get("api-version") != "1" {
+				t.Errorf("query %q lost the existing parameters", req.URL.RawQuery)
+			}
+			if tt.query != "" {
+				if got := query.Get(tt.query); got != tt.apiKey {
+					t.Errorf("%s = %q, want the key", tt.query, got)
+				}
+			} else if len(query) != 1 {
+				t.Errorf("query = %q, want no key", req.URL.RawQuery)
+			}
+		})
+	}
+}
+
+func TestAuthValidate(t *testing.T) {
+	for _, auth := range []Auth{{}, {Type: AuthBearer}, {Type: AuthNone}, {Type: AuthHeader, Name: "api-key"}, {Type: AuthQuery, Name: "key"}} {
+		if err := auth.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v, want nil", auth, err)
+		}
+	}
+	for _, auth := range []Auth{{Type: AuthHeader}, {Type: AuthQuery}, {Type: "basic"}} {
+		if err := auth.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want an error", auth)
+		}
+	}
+}
+
+func TestErrorHidesQuery(t *testing.T) {
+	// A server that is gone makes the request itself fail, with the URL in the error
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	client, err := NewClient(server.URL+"/v1", "secret-key")
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if err := client.SetAuth(Auth{Type: AuthQuery, Name: "key"}); err != nil {
+		t.Fatalf("SetAuth: %v", err)
+	}
+	client.SetQuery(map[string]string{"sig": "secret-signature"})
+
+	_, err = client.ListModels(context.Background())
+	if err == nil {
+		t.Fatal("ListModels succeeded against a closed server")
+	}
+	if msg := err.Error(); strings.Contains(msg, "secret") || !strings.Contains(msg, server.URL+"/v1/models") {
+		t.Errorf("error = %q, want the URL without its query parameters", msg)
+	}
+}
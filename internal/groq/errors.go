@@ -0,0 +1,150 @@
+package groq
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"groq-cli-chat/resources"
+)
+
+// APIError is returned for any non-200 response from the API.
+// It is parsed from the OpenAI error envelope {"error":{...}} when present;
+// Gemini's variant of the envelope is also understood.
+type APIError struct {
+	StatusCode int
+	Type       string // e.g. invalid_request_error
+	Code       string // e.g. model_not_found, context_length_exceeded
+	Message    string
+	RequestID  string
+	RateLimit  RateLimit
+}
+
+// RateLimit holds the rate limit headers of a response.
+// Zero values mean the header was not sent.
+type RateLimit struct {
+	LimitRequests     int
+	LimitTokens       int
+	RemainingRequests int
+	RemainingTokens   int
+	ResetRequests     time.Duration
+	ResetTokens       time.Duration
+	RetryAfter        time.Duration
+}
+
+// newAPIError builds an APIError from a failed response and its body
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("x-request-id"),
+		RateLimit:  parseRateLimit(resp.Header),
+	}
+	if apiErr.RequestID == "" {
+		// Anthropic names the header without the x- prefix
+		apiErr.RequestID = resp.Header.Get("request-id")
+	}
+
+	var envelope struct {
+		Error struct {
+			Message string      `json:"message"`
+			Type    string      `json:"type"`
+			Code    interface{} `json:"code"`   // Some providers send a number
+			Status  string      `json:"status"` // Gemini, e.g. INVALID_ARGUMENT
+			Details []struct {
+				Reason string `json:"reason"` // Gemini, e.g. API_KEY_INVALID
+			} `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Error.Message != "" {
+		apiErr.Message = envelope.Error.Message
+		apiErr.Type = envelope.Error.Type
+		if apiErr.Type == "" {
+			apiErr.Type = envelope.Error.Status
+		}
+		// Gemini and Azure repeat the status as the code
+		if envelope.Error.Code != nil && fmt.Sprint(envelope.Error.Code) != strconv.Itoa(resp.StatusCode) {
+			apiErr.Code = fmt.Sprint(envelope.Error.Code)
+		}
+		for _, detail := range envelope.Error.Details {
+			if apiErr.Code == "" && detail.Reason != "" {
+				apiErr.Code = detail.Reason
+			}
+		}
+	} else if message, ok := plainErrorMessage(body); ok {
+		apiErr.Message = message
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+
+	return apiErr
+}
+
+// plainErrorMessage reads the {"error":"message"} body sent by Ollama
+func plainErrorMessage(body []byte) (string, bool) {
+	var plain struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &plain); err != nil || plain.Error == "" {
+		return "", false
+	}
+	return plain.Error, true
+}
+
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf(resources.ErrAPICode, e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf(resources.ErrAPI, e.StatusCode, e.Message)
+}
+
+// IsAuth reports whether the API key was missing, invalid or lacks permission
+func (e *APIError) IsAuth() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden ||
+		e.Code == "invalid_api_key" || e.Code == "API_KEY_INVALID"
+}
+
+// IsRateLimit reports whether a rate limit or quota was exceeded
+func (e *APIError) IsRateLimit() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.Code == "rate_limit_exceeded"
+}
+
+// IsModelUnavailable reports whether the model does not exist or was decommissioned
+func (e *APIError) IsModelUnavailable() bool {
+	return e.Code == "model_not_found" || e.Code == "model_decommissioned"
+}
+
+// IsContextLength reports whether the request exceeded the model's context window
+func (e *APIError) IsContextLength() bool {
+	return e.Code == "context_length_exceeded" || e.StatusCode == http.StatusRequestEntityTooLarge
+}
+
+// parseRateLimit reads the x-ratelimit-* and Retry-After headers
+func parseRateLimit(header http.Header) RateLimit {
+	atoi := func(name string) int {
+		n, _ := strconv.Atoi(header.Get(name))
+		return n
+	}
+	duration := func(name string) time.Duration {
+		d, _ := time.ParseDuration(header.Get(name))
+		return d
+	}
+
+	limit := RateLimit{
+		LimitRequests:     atoi("x-ratelimit-limit-requests"),
+		LimitTokens:       atoi("x-ratelimit-limit-tokens"),
+		RemainingRequests: atoi("x-ratelimit-remaining-requests"),
+		RemainingTokens:   atoi("x-ratelimit-remaining-tokens"),
+		ResetRequests:     duration("x-ratelimit-reset-requests"),
+		ResetTokens:       duration("x-ratelimit-reset-tokens"),
+	}
+	if d, ok := serverDelay(header); ok {
+		limit.RetryAfter = d
+	}
+	return limit
+}
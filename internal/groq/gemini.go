@@ -0,0 +1,311 @@
+package groq
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"groq-cli-chat/resources"
+)
+
+// geminiProvider is the Provider for the Google Gemini generateContent API
+type geminiProvider struct {
+	client *Client
+}
+
+var _ Provider = (*geminiProvider)(nil)
+
+// newGeminiProvider uses client for transport, authenticating with the key
+// query parameter unless another auth is configured
+func newGeminiProvider(client *Client) *geminiProvider {
+	if client.auth.Type == "" {
+		client.auth = Auth{Type: AuthQuery, Name: "key"}
+	}
+	return &geminiProvider{client: client}
+}
+
+// geminiRequest is the payload of a generateContent request
+type geminiRequest struct {
+	Contents          []geminiContent        `json:"contents"`
+	SystemInstruction *geminiContent         `json:"systemInstruction,omitempty"`
+	GenerationConfig  geminiGenerationConfig `json:"generationConfig"`
+}
+
+// geminiContent is a message made of parts; the assistant role is called "model"
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+// geminiPart is a part of a content; only text parts are sent and read
+type geminiPart struct {
+	Text    string `json:"text"`
+	Thought bool   `json:"thought,omitempty"`
+}
+
+// geminiGenerationConfig holds the generation parameters
+type geminiGenerationConfig struct {
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"topP,omitempty"`
+	MaxOutputTokens  *int     `json:"maxOutputTokens,omitempty"`
+	StopSequences    []string `json:"stopSequences,omitempty"`
+	Seed             *int     `json:"seed,omitempty"`
+	FrequencyPenalty *float64 `json:"frequencyPenalty,omitempty"`
+	PresencePenalty  *float64 `json:"presencePenalty,omitempty"`
+}
+
+// geminiResponse is the response of a generateContent request, or a single
+// event of a streamed one
+type geminiResponse struct {
+	ResponseID string `json:"responseId"`
+	Candidates []struct {
+		Content geminiContent `json:"content"`
+	} `json:"candidates"`
+	UsageMetadata *geminiUsage `json:"usageMetadata"`
+}
+
+// geminiUsage holds token counts. Thinking tokens are billed as output
+// and are added to the completion tokens of our stats.
+type geminiUsage struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	ThoughtsTokenCount   int `json:"thoughtsTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
+}
+
+// geminiModel is an entry of the models endpoint
+type geminiModel struct {
+	Name                       string   `json:"name"` // e.g. models/gemini-2.5-flash
+	InputTokenLimit            int      `json:"inputTokenLimit"`
+	SupportedGenerationMethods []string `json:"supportedGenerationMethods"`
+}
+
+func (u *geminiUsage) usage() Usage {
+	if u == nil {
+		return Usage{}
+	}
+	completion := u.CandidatesTokenCount + u.ThoughtsTokenCount
+	total := u.TotalTokenCount
+	if total == 0 {
+		total = u.PromptTokenCount + completion
+	}
+	return Usage{
+		PromptTokens:     u.PromptTokenCount,
+		CompletionTokens: completion,
+		TotalTokens:      total,
+	}
+}
+
+// text returns the answer of the first candidate, without thought summaries
+func (r *geminiResponse) text() string {
+	if len(r.Candidates) == 0 {
+		return ""
+	}
+	var text strings.Builder
+	for _, part := range r.Candidates[0].Content.Parts {
+		if !part.Thought {
+			text.WriteString(part.Text)
+		}
+	}
+	return text.String()
+}
+
+// newGeminiRequest moves system messages to the system instruction and
+// sends the other messages as contents with a single text part
+func newGeminiRequest(messages []Message, params Parameters) geminiRequest {
+	req := geminiRequest{
+		GenerationConfig: geminiGenerationConfig{
+			Temperature:      params.Temperature,
+			TopP:             params.TopP,
+			MaxOutputTokens:  params.MaxTokens,
+			StopSequences:    params.Stop,
+			Seed:             params.Seed,
+			FrequencyPenalty: params.FrequencyPenalty,
+			PresencePenalty:  params.PresencePenalty,
+		},
+	}
+
+	for _, msg := range messages {
+		switch msg.Role {
+		case RoleSystem:
+			if req.SystemInstruction == nil {
+				req.SystemInstruction = &geminiContent{}
+			}
+			req.SystemInstruction.Parts = append(req.SystemInstruction.Parts, geminiPart{Text: msg.Content})
+		case RoleAssistant:
+			req.Contents = append(req.Contents, geminiContent{Role: "model", Parts: []geminiPart{{Text: msg.Content}}})
+		default:
+			req.Contents = append(req.Contents, geminiContent{Role: RoleUser, Parts: []geminiPart{{Text: msg.Content}}})
+		}
+	}
+
+	return req
+}
+
+// geminiModelPath returns the endpoint of a model; IDs are accepted with or without the models/ prefix
+func geminiModelPath(model string) string {
+	return "models/" + url.PathEscape(strings.TrimPrefix(model, "models/"))
+}
+
+// ListModels returns the models supporting generateContent, without the models/ prefix
+func (p *geminiProvider) ListModels(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+
+	var models []string
+	endpoint := "models?pageSize=1000"
+	for {
+		resp, err := p.client.makeRequest(ctx, "GET", endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Models        []geminiModel `json:"models"`
+			NextPageToken string        `json:"nextPageToken"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf(resources.ErrDecodeResponse, err)
+		}
+
+		for _, model := range page.Models {
+			if slices.Contains(model.SupportedGenerationMethods, "generateContent") {
+				models = append(models, strings.TrimPrefix(model.Name, "models/"))
+			}
+		}
+		if page.NextPageToken == "" {
+			return models, nil
+		}
+		endpoint = "models?pageSize=1000&pageToken=" + url.QueryEscape(page.NextPageToken)
+	}
+}
+
+func (p *geminiProvider) GetModel(ctx context.Context, model string) (*ModelInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+
+	resp, err := p.client.makeRequest(ctx, "GET", geminiModelPath(model), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var info geminiModel
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf(resources.ErrDecodeResponse, err)
+	}
+
+	return &ModelInfo{
+		ID:            strings.TrimPrefix(info.Name, "models/"),
+		Object:        "model",
+		OwnedBy:       "google",
+		Active:        true,
+		ContextWindow: info.InputTokenLimit,
+	}, nil
+}
+
+func (p *geminiProvider) Chat(ctx context.Context, model string, messages []Message, params Parameters) (*ChatResponse, error) {
+	// Start timing the request
+	startTime := time.Now()
+
+	body, err := json.Marshal(newGeminiRequest(messages, params))
+	if err != nil {
+		return nil, fmt.Errorf(resources.ErrEncodePayload, err)
+	}
+
+	resp, err := p.client.makeRequest(ctx, "POST", geminiModelPath(model)+":generateContent", body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result geminiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf(resources.ErrDecodeResponse, err)
+	}
+
+	content := result.text()
+	if content == "" {
+		return nil, fmt.Errorf(resources.ErrEmptyResponse)
+	}
+
+	chatResp := &ChatResponse{
+		ID:      result.ResponseID,
+		Choices: []Choice{{Message: Message{Role: RoleAssistant, Content: content}}},
+		Usage:   result.UsageMetadata.usage(),
+	}
+	chatResp.Usage.fillTimes(time.Since(startTime).Seconds())
+
+	return chatResp, nil
+}
+
+func (p *geminiProvider) ChatStream(ctx context.Context, model string, messages []Message, params Parameters, onDelta func(string)) (*ChatResponse, error) {
+	// Start timing the request
+	startTime := time.Now()
+
+	body, err := json.Marshal(newGeminiRequest(messages, params))
+	if err != nil {
+		return nil, fmt.Errorf(resources.ErrEncodePayload, err)
+	}
+
+	resp, err := p.client.makeRequest(ctx, "POST", geminiModelPath(model)+":streamGenerateContent?alt=sse", body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	var usage *geminiUsage
+	var id string
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		var chunk geminiResponse
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &chunk); err != nil {
+			return nil, fmt.Errorf(resources.ErrDecodeResponse, err)
+		}
+
+		if text := chunk.text(); text != "" {
+			content.WriteString(text)
+			if onDelta != nil {
+				onDelta(text)
+			}
+		}
+		if chunk.ResponseID != "" {
+			id = chunk.ResponseID
+		}
+		// Every chunk reports the usage so far
+		if chunk.UsageMetadata != nil {
+			usage = chunk.UsageMetadata
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf(resources.ErrReadStream, err)
+	}
+
+	if content.Len() == 0 {
+		return nil, fmt.Errorf(resources.ErrEmptyResponse)
+	}
+
+	chatResp := &ChatResponse{
+		ID:      id,
+		Choices: []Choice{{Message: Message{Role: RoleAssistant, Content: content.String()}}},
+		Usage:   usage.usage(),
+	}
+	chatResp.Usage.fillTimes(time.Since(startTime).Seconds())
+
+	return chatResp, nil
+}
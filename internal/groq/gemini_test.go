@@ -0,0 +1,152 @@
+package groq
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// geminiServer returns a Gemini provider for a server answering every request
+// for the test model with body. The decoded request is stored in req, if not nil.
+func geminiServer(t *testing.T, body string, req *geminiRequest) Provider {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/models/gemini-test") {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.URL.Query().Get("key"); got != "test-key" {
+			t.Errorf("key = %q, want the API key", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Errorf("Authorization = %q, want none", got)
+		}
+		if req != nil && r.Method == http.MethodPost {
+			if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+				t.Errorf("decode request: %v", err)
+			}
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	provider, err := NewProvider(Options{Type: TypeGemini, BaseURL: server.URL, APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+	return provider
+}
+
+func TestGeminiChat(t *testing.T) {
+	body := `{"responseId":"resp_1",
+		"candidates":[{"content":{"role":"model","parts":[{"text":"thinking...","thought":true},{"text":"Hi "},{"text":"there"}]}}],
+		"usageMetadata":{"promptTokenCount":10,"candidatesTokenCount":3,"thoughtsTokenCount":4,"totalTokenCount":17}}`
+	var req geminiRequest
+	provider := geminiServer(t, body, &req)
+
+	temperature, maxTokens := 0.5, 100
+	params := Parameters{Temperature: &temperature, MaxTokens: &maxTokens, Stop: []string{"END"}}
+	resp, err := provider.Chat(context.Background(), "models/gemini-test", anthropicMessages, params)
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	// The system prompt becomes the system instruction, the assistant is called model
+	if req.SystemInstruction == nil || len(req.SystemInstruction.Parts) != 1 || req.SystemInstruction.Parts[0].Text != "Be brief." {
+		t.Errorf("system instruction = %+v, want the system prompt", req.SystemInstruction)
+	}
+	var roles []string
+	for _, content := range req.Contents {
+		roles = append(roles, content.Role)
+	}
+	if strings.Join(roles, ",") != "user,model,user" || req.Contents[2].Parts[0].Text != "again" {
+		t.Errorf("contents = %+v, want user, model, user", req.Contents)
+	}
+	config := req.GenerationConfig
+	if config.Temperature == nil || *config.Temperature != 0.5 || config.MaxOutputTokens == nil || *config.MaxOutputTokens != 100 ||
+		len(config.StopSequences) != 1 || config.TopP != nil {
+		t.Errorf("generation config = %+v, want temperature, max output tokens and stop sequences", config)
+	}
+
+	if got := resp.Choices[0].Message.Content; got != "Hi there" {
+		t.Errorf("content = %q, want the text parts without thoughts", got)
+	}
+	// Thinking tokens count as completion tokens
+	if resp.Usage.PromptTokens != 10 || resp.Usage.CompletionTokens != 7 || resp.Usage.TotalTokens != 17 {
+		t.Errorf("usage = %+v, want 10 + 7 tokens", resp.Usage)
+	}
+	if resp.ID != "resp_1" {
+		t.Errorf("ID = %q, want resp_1", resp.ID)
+	}
+}
+
+func TestGeminiUsageTotal(t *testing.T) {
+	// A missing total is computed from the other counts
+	usage := (&geminiUsage{PromptTokenCount: 5, CandidatesTokenCount: 2, ThoughtsTokenCount: 1}).usage()
+	if usage.TotalTokens != 8 || usage.CompletionTokens != 3 {
+		t.Errorf("usage = %+v, want 5 + 3 tokens", usage)
+	}
+	if usage := (*geminiUsage)(nil).usage(); usage != (Usage{}) {
+		t.Errorf("usage without metadata = %+v, want zero", usage)
+	}
+}
+
+func TestGeminiGetModel(t *testing.T) {
+	body := `{"name":"models/gemini-test","inputTokenLimit":1048576,"supportedGenerationMethods":["generateContent"]}`
+	provider := geminiServer(t, body, nil)
+
+	info, err := provider.GetModel(context.Background(), "gemini-test")
+	if err != nil {
+		t.Fatalf("GetModel: %v", err)
+	}
+	if info.ID != "gemini-test" || info.ContextWindow != 1048576 || info.OwnedBy != "google" || !info.Active {
+		t.Errorf("model = %+v, want gemini-test with its input token limit", info)
+	}
+}
+
+func TestGeminiChatStream(t *testing.T) {
+	body := `data: {"responseId":"resp_2","candidates":[{"content":{"role":"model","parts":[{"text":"hmm","thought":true}]}}],"usageMetadata":{"promptTokenCount":12}}
+
+data: {"responseId":"resp_2","candidates":[{"content":{"role":"model","parts":[{"text":"Hel"}]}}],"usageMetadata":{"promptTokenCount":12,"candidatesTokenCount":1}}
+
+data: {"responseId":"resp_2","candidates":[{"content":{"role":"model","parts":[{"text":"lo"}]}}],"usageMetadata":{"promptTokenCount":12,"candidatesTokenCount":2,"totalTokenCount":14}}
+`
+	var req geminiRequest
+	provider := geminiServer(t, body, &req)
+
+	var deltas []string
+	resp, err := provider.ChatStream(context.Background(), "gemini-test", anthropicMessages[1:], Parameters{}, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+
+	if req.SystemInstruction != nil {
+		t.Errorf("system instruction = %+v, want none", req.SystemInstruction)
+	}
+	if strings.Join(deltas, "|") != "Hel|lo" {
+		t.Errorf("deltas = %q, want the text parts only", deltas)
+	}
+	if got := resp.Choices[0].Message.Content; got != "Hello" {
+		t.Errorf("content = %q, want Hello", got)
+	}
+	// The usage of the last chunk is the total
+	if resp.Usage.PromptTokens != 12 || resp.Usage.CompletionTokens != 2 || resp.Usage.TotalTokens != 14 || resp.ID != "resp_2" {
+		t.Errorf("ID %q, usage %+v; want resp_2 with 12 + 2 tokens", resp.ID, resp.Usage)
+	}
+}
+
+func TestGeminiChatStreamEmpty(t *testing.T) {
+	body := `data: {"candidates":[{"content":{"parts":[{"text":"only a thought","thought":true}]}}]}
+`
+	provider := geminiServer(t, body, nil)
+
+	_, err := provider.ChatStream(context.Background(), "gemini-test", anthropicMessages, Parameters{}, nil)
+	if err == nil || !strings.Contains(strings.ToLower(err.Error()), "empty") {
+		t.Errorf("error = %v, want an empty response", err)
+	}
+}
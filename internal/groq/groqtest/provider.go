@@ -0,0 +1,110 @@
+// Package groqtest provides an in-memory groq.Provider for tests.
+package groqtest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"groq-cli-chat/internal/groq"
+)
+
+// Request is a chat request received by a Provider
+type Request struct {
+	Model      string
+	Messages   []groq.Message
+	Parameters groq.Parameters
+	Stream     bool
+}
+
+// Provider is a fake groq.Provider. It answers every chat request with Reply,
+// or with Err if set, and records the requests it receives.
+type Provider struct {
+	Models []string             // Returned by ListModels
+	Reply  func(Request) string // Response content; echoes the last message when nil
+	Usage  groq.Usage           // Reported with every response
+	Err    error                // Returned instead of a response when set
+
+	mu       sync.Mutex
+	requests []Request
+}
+
+var _ groq.Provider = (*Provider)(nil)
+
+// New returns a fake provider offering models
+func New(models ...string) *Provider {
+	return &Provider{Models: models}
+}
+
+// Requests returns the chat requests received so far, oldest first
+func (p *Provider) Requests() []Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Request(nil), p.requests...)
+}
+
+// ListModels returns the configured models
+func (p *Provider) ListModels(ctx context.Context) ([]string, error) {
+	if p.Err != nil {
+		return nil, p.Err
+	}
+	return append([]string(nil), p.Models...), nil
+}
+
+// GetModel returns the details of a configured model
+func (p *Provider) GetModel(ctx context.Context, model string) (*groq.ModelInfo, error) {
+	if p.Err != nil {
+		return nil, p.Err
+	}
+	for _, m := range p.Models {
+		if m == model {
+			return &groq.ModelInfo{ID: m, Object: "model", OwnedBy: "groqtest", Active: true}, nil
+		}
+	}
+	return nil, fmt.Errorf("model not found: %s", model)
+}
+
+// Chat records the request and returns the reply
+func (p *Provider) Chat(ctx context.Context, model string, messages []groq.Message, params groq.Parameters) (*groq.ChatResponse, error) {
+	return p.respond(ctx, Request{Model: model, Messages: messages, Parameters: params})
+}
+
+// ChatStream records the request and passes the reply to onDelta in one fragment
+func (p *Provider) ChatStream(ctx context.Context, model string, messages []groq.Message, params groq.Parameters, onDelta func(string)) (*groq.ChatResponse, error) {
+	resp, err := p.respond(ctx, Request{Model: model, Messages: messages, Parameters: params, Stream: true})
+	if err != nil {
+		return nil, err
+	}
+	if onDelta != nil {
+		onDelta(resp.Choices[0].Message.Content)
+	}
+	return resp, nil
+}
+
+func (p *Provider) respond(ctx context.Context, req Request) (*groq.ChatResponse, error) {
+	req.Messages = append([]groq.Message(nil), req.Messages...)
+	p.mu.Lock()
+	p.requests = append(p.requests, req)
+	n := len(p.requests)
+	p.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if p.Err != nil {
+		return nil, p.Err
+	}
+
+	var content string
+	if p.Reply != nil {
+		content = p.Reply(req)
+	} else if len(req.Messages) > 0 {
+		content = req.Messages[len(req.Messages)-1].Content
+	}
+
+	return &groq.ChatResponse{
+		ID:      fmt.Sprintf("groqtest-%d", n),
+		Choices: []groq.Choice{{Message: groq.Message{Role: groq.RoleAssistant, Content: content}}},
+		Usage:   p.Usage,
+	}, nil
+}
@@ -0,0 +1,267 @@
+package groq
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"groq-cli-chat/resources"
+)
+
+// ollamaProvider is the Provider for the native Ollama API.
+// Unlike its OpenAI-compatible endpoint, the native API reports timings and context lengths.
+type ollamaProvider struct {
+	client *Client
+}
+
+var _ Provider = (*ollamaProvider)(nil)
+
+// newOllamaProvider uses client for transport; a key, if any, is sent as a bearer token
+// unless another auth is configured
+func newOllamaProvider(client *Client) *ollamaProvider {
+	return &ollamaProvider{client: client}
+}
+
+// ollamaRequest is the payload of a chat request
+type ollamaRequest struct {
+	Model    string        `json:"model"`
+	Messages []Message     `json:"messages"`
+	Stream   bool          `json:"stream"`
+	Options  ollamaOptions `json:"options"`
+}
+
+// ollamaOptions holds the generation parameters
+type ollamaOptions struct {
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"top_p,omitempty"`
+	NumPredict       *int     `json:"num_predict,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+	Seed             *int     `json:"seed,omitempty"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+}
+
+// ollamaResponse is the response of a chat request, or a single line of a
+// streamed one. Counts and durations are only sent when done is true.
+type ollamaResponse struct {
+	Message            Message `json:"message"`
+	Done               bool    `json:"done"`
+	Error              string  `json:"error"`
+	TotalDuration      int64   `json:"total_duration"` // Nanoseconds
+	LoadDuration       int64   `json:"load_duration"`
+	PromptEvalCount    int     `json:"prompt_eval_count"`
+	PromptEvalDuration int64   `json:"prompt_eval_duration"`
+	EvalCount          int     `json:"eval_count"`
+	EvalDuration       int64   `json:"eval_duration"`
+}
+
+// usage converts the counts and durations of the final response.
+// The time spent loading the model is reported as queue time.
+func (r *ollamaResponse) usage() Usage {
+	return Usage{
+		PromptTokens:     r.PromptEvalCount,
+		CompletionTokens: r.EvalCount,
+		TotalTokens:      r.PromptEvalCount + r.EvalCount,
+		PromptTime:       time.Duration(r.PromptEvalDuration).Seconds(),
+		CompletionTime:   time.Duration(r.EvalDuration).Seconds(),
+		QueueTime:        time.Duration(r.LoadDuration).Seconds(),
+		TotalTime:        time.Duration(r.TotalDuration).Seconds(),
+	}
+}
+
+func newOllamaRequest(model string, messages []Message, params Parameters, stream bool) ollamaRequest {
+	return ollamaRequest{
+		Model:    model,
+		Messages: messages,
+		Stream:   stream,
+		Options: ollamaOptions{
+			Temperature:      params.Temperature,
+			TopP:             params.TopP,
+			NumPredict:       params.MaxTokens,
+			Stop:             params.Stop,
+			Seed:             params.Seed,
+			FrequencyPenalty: params.FrequencyPenalty,
+			PresencePenalty:  params.PresencePenalty,
+		},
+	}
+}
+
+// ListModels returns the locally installed models
+func (p *ollamaProvider) ListModels(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+
+	resp, err := p.client.makeRequest(ctx, "GET", "api/tags", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf(resources.ErrDecodeResponse, err)
+	}
+
+	models := make([]string, len(result.Models))
+	for i, model := range result.Models {
+		models[i] = model.Name
+	}
+	return models, nil
+}
+
+// GetModel returns the details of an installed model, with the context
+// length reported in its model_info
+func (p *ollamaProvider) GetModel(ctx context.Context, model string) (*ModelInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+	defer cancel()
+
+	body, err := json.Marshal(map[string]string{"model": model})
+	if err != nil {
+		return nil, fmt.Errorf(resources.ErrEncodePayload, err)
+	}
+
+	resp, err := p.client.makeRequest(ctx, "POST", "api/show", body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		ModifiedAt time.Time `json:"modified_at"`
+		Details    struct {
+			Family string `json:"family"`
+		} `json:"details"`
+		ModelInfo map[string]interface{} `json:"model_info"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf(resources.ErrDecodeResponse, err)
+	}
+
+	info := &ModelInfo{
+		ID:      model,
+		Object:  "model",
+		Created: result.ModifiedAt.Unix(),
+		OwnedBy: result.Details.Family,
+		Active:  true,
+	}
+
+	// The key is prefixed with the architecture, e.g. llama.context_length
+	arch, _ := result.ModelInfo["general.architecture"].(string)
+	if length, ok := result.ModelInfo[arch+".context_length"].(float64); ok {
+		info.ContextWindow = int(length)
+	} else {
+		for key, value := range result.ModelInfo {
+			if length, ok := value.(float64); ok && strings.HasSuffix(key, ".context_length") {
+				info.ContextWindow = int(length)
+				break
+			}
+		}
+	}
+
+	return info, nil
+}
+
+func (p *ollamaProvider) Chat(ctx context.Context, model string, messages []Message, params Parameters) (*ChatResponse, error) {
+	// Start timing the request
+	startTime := time.Now()
+
+	body, err := json.Marshal(newOllamaRequest(model, messages, params, false))
+	if err != nil {
+		return nil, fmt.Errorf(resources.ErrEncodePayload, err)
+	}
+
+	resp, err := p.client.makeRequest(ctx, "POST", "api/chat", body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result ollamaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf(resources.ErrDecodeResponse, err)
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf(resources.ErrStreamEvent, "error", result.Error)
+	}
+	if result.Message.Content == "" {
+		return nil, fmt.Errorf(resources.ErrEmptyResponse)
+	}
+
+	chatResp := &ChatResponse{
+		Choices: []Choice{{Message: Message{Role: RoleAssistant, Content: result.Message.Content}}},
+		Usage:   result.usage(),
+	}
+	chatResp.Usage.fillTimes(time.Since(startTime).Seconds())
+
+	return chatResp, nil
+}
+
+// ChatStream reads the response as newline-delimited JSON objects, the last one marked done
+func (p *ollamaProvider) ChatStream(ctx context.Context, model string, messages []Message, params Parameters, onDelta func(string)) (*ChatResponse, error) {
+	// Start timing the request
+	startTime := time.Now()
+
+	body, err := json.Marshal(newOllamaRequest(model, messages, params, true))
+	if err != nil {
+		return nil, fmt.Errorf(resources.ErrEncodePayload, err)
+	}
+
+	resp, err := p.client.makeRequest(ctx, "POST", "api/chat", body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	var usage Usage
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var chunk ollamaResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return nil, fmt.Errorf(resources.ErrDecodeResponse, err)
+		}
+		if chunk.Error != "" {
+			return nil, fmt.Errorf(resources.ErrStreamEvent, "error", chunk.Error)
+		}
+
+		if chunk.Message.Content != "" {
+			content.WriteString(chunk.Message.Content)
+			if onDelta != nil {
+				onDelta(chunk.Message.Content)
+			}
+		}
+		if chunk.Done {
+			usage = chunk.usage()
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf(resources.ErrReadStream, err)
+	}
+
+	if content.Len() == 0 {
+		return nil, fmt.Errorf(resources.ErrEmptyResponse)
+	}
+
+	chatResp := &ChatResponse{
+		Choices: []Choice{{Message: Message{Role: RoleAssistant, Content: content.String()}}},
+		Usage:   usage,
+	}
+	chatResp.Usage.fillTimes(time.Since(startTime).Seconds())
+
+	return chatResp, nil
+}
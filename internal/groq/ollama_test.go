@@ -0,0 +1,170 @@
+package groq
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// ollamaServer returns an Ollama provider for a server answering each path,
+// e.g. /api/chat, with its body. The decoded chat request is stored in req,
+// if not nil.
+func ollamaServer(t *testing.T, bodies map[string]string, req *ollamaRequest) Provider {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := bodies[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Errorf("Authorization = %q, want none without a key", got)
+		}
+		if req != nil && r.URL.Path == "/api/chat" {
+			if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+				t.Errorf("decode request: %v", err)
+			}
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	provider, err := NewProvider(Options{Type: TypeOllama, BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+	return provider
+}
+
+// approx reports whether two durations in seconds are equal up to rounding
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestOllamaChatStream(t *testing.T) {
+	body := `{"model":"llama3","message":{"role":"assistant","content":"Hel"},"done":false}
+
+{"model":"llama3","message":{"role":"assistant","content":"lo"},"done":false}
+{"model":"llama3","message":{"role":"assistant","content":""},"done":true,"total_duration":3000000000,"load_duration":500000000,"prompt_eval_count":12,"prompt_eval_duration":250000000,"eval_count":4,"eval_duration":2000000000}
+{"model":"llama3","message":{"role":"assistant","content":"not read after done"},"done":false}
+`
+	var req ollamaRequest
+	provider := ollamaServer(t, map[string]string{"/api/chat": body}, &req)
+
+	temperature, maxTokens := 0.5, 100
+	var deltas []string
+	resp, err := provider.ChatStream(context.Background(), "llama3", anthropicMessages,
+		Parameters{Temperature: &temperature, MaxTokens: &maxTokens}, func(delta string) {
+			deltas = append(deltas, delta)
+		})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+
+	// Messages are sent as they are, the system prompt included
+	if req.Model != "llama3" || !req.Stream || len(req.Messages) != len(anthropicMessages) || req.Messages[0].Role != RoleSystem {
+		t.Errorf("request = %+v, want the streamed conversation", req)
+	}
+	if req.Options.Temperature == nil || *req.Options.Temperature != 0.5 || req.Options.NumPredict == nil || *req.Options.NumPredict != 100 {
+		t.Errorf("options = %+v, want temperature and num_predict", req.Options)
+	}
+
+	if strings.Join(deltas, "|") != "Hel|lo" {
+		t.Errorf("deltas = %q, want Hel and lo", deltas)
+	}
+	if got := resp.Choices[0].Message.Content; got != "Hello" {
+		t.Errorf("content = %q, want Hello", got)
+	}
+
+	// Counts and nanosecond durations come from the final line; loading the model is queue time
+	usage := resp.Usage
+	if usage.PromptTokens != 12 || usage.CompletionTokens != 4 || usage.TotalTokens != 16 {
+		t.Errorf("usage = %+v, want 12 + 4 tokens", usage)
+	}
+	if !approx(usage.PromptTime, 0.25) || !approx(usage.CompletionTime, 2) || !approx(usage.QueueTime, 0.5) || !approx(usage.TotalTime, 3) {
+		t.Errorf("times = %+v, want the reported durations in seconds", usage)
+	}
+}
+
+func TestOllamaChatStreamError(t *testing.T) {
+	body := `{"message":{"role":"assistant","content":"a"},"done":false}
+{"error":"model requires more system memory"}
+`
+	provider := ollamaServer(t, map[string]string{"/api/chat": body}, nil)
+
+	_, err := provider.ChatStream(context.Background(), "llama3", anthropicMessages, Parameters{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "system memory") {
+		t.Errorf("error = %v, want the stream error", err)
+	}
+}
+
+func TestOllamaChat(t *testing.T) {
+	body := `{"message":{"role":"assistant","content":"Hi"},"done":true,"prompt_eval_count":5,"eval_count":1,"eval_duration":100000000}`
+	var req ollamaRequest
+	provider := ollamaServer(t, map[string]string{"/api/chat": body}, &req)
+
+	resp, err := provider.Chat(context.Background(), "llama3", anthropicMessages, Parameters{})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if req.Stream {
+		t.Error("request is streamed")
+	}
+	if resp.Choices[0].Message.Content != "Hi" || resp.Usage.TotalTokens != 6 || !approx(resp.Usage.CompletionTime, 0.1) {
+		t.Errorf("response = %+v, want Hi with 5 + 1 tokens", resp)
+	}
+}
+
+func TestOllamaGetModel(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{
+			name: "architecture key",
+			body: `{"modified_at":"2025-01-02T03:04:05Z","details":{"family":"llama"},
+				"model_info":{"general.architecture":"llama","llama.context_length":131072,"other.context_length":1}}`,
+			want: 131072,
+		},
+		{
+			name: "any context length",
+			body: `{"details":{"family":"qwen2"},"model_info":{"qwen2.context_length":32768}}`,
+			want: 32768,
+		},
+		{
+			name: "unknown",
+			body: `{"details":{"family":"bert"},"model_info":{}}`,
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := ollamaServer(t, map[string]string{"/api/show": tt.body}, nil)
+
+			info, err := provider.GetModel(context.Background(), "llama3")
+			if err != nil {
+				t.Fatalf("GetModel: %v", err)
+			}
+			if info.ID != "llama3" || info.ContextWindow != tt.want || !info.Active {
+				t.Errorf("model = %+v, want llama3 with a context window of %d", info, tt.want)
+			}
+		})
+	}
+}
+
+func TestOllamaListModels(t *testing.T) {
+	provider := ollamaServer(t, map[string]string{"/api/tags": `{"models":[{"name":"llama3:latest"},{"name":"qwen2:7b"}]}`}, nil)
+
+	models, err := provider.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels: %v", err)
+	}
+	if strings.Join(models, ",") != "llama3:latest,qwen2:7b" {
+		t.Errorf("models = %q, want the installed models", models)
+	}
+}
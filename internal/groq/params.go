@@ -0,0 +1,144 @@
+package groq
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"groq-cli-chat/resources"
+)
+
+// Parameters holds optional generation parameters of a chat request.
+// Nil fields are not sent, so the provider's defaults apply.
+type Parameters struct {
+	Temperature      *float64 `json:"temperature,omitempty" mapstructure:"temperature"`
+	TopP             *float64 `json:"top_p,omitempty" mapstructure:"top_p"`
+	MaxTokens        *int     `json:"max_tokens,omitempty" mapstructure:"max_tokens"`
+	Stop             []string `json:"stop,omitempty" mapstructure:"stop"`
+	Seed             *int     `json:"seed,omitempty" mapstructure:"seed"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty" mapstructure:"frequency_penalty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty" mapstructure:"presence_penalty"`
+}
+
+// ParameterNames lists the names accepted by Parameters.Set
+var ParameterNames = []string{
+	"temperature", "top_p", "max_tokens", "stop", "seed", "frequency_penalty", "presence_penalty",
+}
+
+// Merge returns p with every field set in override replaced
+func (p Parameters) Merge(override Parameters) Parameters {
+	if override.Temperature != nil {
+		p.Temperature = override.Temperature
+	}
+	if override.TopP != nil {
+		p.TopP = override.TopP
+	}
+	if override.MaxTokens != nil {
+		p.MaxTokens = override.MaxTokens
+	}
+	if override.Stop != nil {
+		p.Stop = override.Stop
+	}
+	if override.Seed != nil {
+		p.Seed = override.Seed
+	}
+	if override.FrequencyPenalty != nil {
+		p.FrequencyPenalty = override.FrequencyPenalty
+	}
+	if override.PresencePenalty != nil {
+		p.PresencePenalty = override.PresencePenalty
+	}
+	return p
+}
+
+// Set parses value and assigns it to the named parameter.
+// The value "default" clears the parameter. Stop sequences are comma separated
+// and may contain escapes such as \n.
+func (p *Parameters) Set(name, value string) error {
+	value = strings.TrimSpace(value)
+	clear := value == "default"
+
+	parseFloat := func(target **float64) error {
+		if clear {
+			*target = nil
+			return nil
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf(resources.ErrInvalidParamValue, name, value)
+		}
+		*target = &f
+		return nil
+	}
+	parseInt := func(target **int) error {
+		if clear {
+			*target = nil
+			return nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf(resources.ErrInvalidParamValue, name, value)
+		}
+		*target = &n
+		return nil
+	}
+
+	switch strings.ToLower(name) {
+	case "temperature":
+		return parseFloat(&p.Temperature)
+	case "top_p":
+		return parseFloat(&p.TopP)
+	case "max_tokens":
+		return parseInt(&p.MaxTokens)
+	case "seed":
+		return parseInt(&p.Seed)
+	case "frequency_penalty":
+		return parseFloat(&p.FrequencyPenalty)
+	case "presence_penalty":
+		return parseFloat(&p.PresencePenalty)
+	case "stop":
+		if clear {
+			p.Stop = nil
+			return nil
+		}
+		var stop []string
+		for _, seq := range strings.Split(value, ",") {
+			if unquoted, err := strconv.Unquote(`"` + seq + `"`); err == nil {
+				seq = unquoted
+			}
+			if seq != "" {
+				stop = append(stop, seq)
+			}
+		}
+		p.Stop = stop
+		return nil
+	}
+	return fmt.Errorf(resources.ErrUnknownParam, name, strings.Join(ParameterNames, ", "))
+}
+
+// String formats the set parameters as name=value pairs, e.g. "temperature=0.2 seed=42"
+func (p Parameters) String() string {
+	var parts []string
+	addFloat := func(name string, f *float64) {
+		if f != nil {
+			parts = append(parts, fmt.Sprintf("%s=%g", name, *f))
+		}
+	}
+	addInt := func(name string, n *int) {
+		if n != nil {
+			parts = append(parts, fmt.Sprintf("%s=%d", name, *n))
+		}
+	}
+
+	addFloat("temperature", p.Temperature)
+	addFloat("top_p", p.TopP)
+	addInt("max_tokens", p.MaxTokens)
+	if len(p.Stop) > 0 {
+		parts = append(parts, fmt.Sprintf("stop=%q", p.Stop))
+	}
+	addInt("seed", p.Seed)
+	addFloat("frequency_penalty", p.FrequencyPenalty)
+	addFloat("presence_penalty", p.PresencePenalty)
+
+	return strings.Join(parts, " ")
+}
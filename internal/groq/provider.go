@@ -0,0 +1,93 @@
+package groq
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"groq-cli-chat/resources"
+)
+
+// Provider is a chat backend. Client implements it for OpenAI-compatible APIs;
+// other APIs get an adapter translating to and from these types.
+type Provider interface {
+	// ListModels returns the IDs of the available models
+	ListModels(ctx context.Context) ([]string, error)
+
+	// GetModel returns the details of a model
+	GetModel(ctx context.Context, model string) (*ModelInfo, error)
+
+	// Chat sends the conversation, oldest message first and starting with the
+	// system message if any, and returns the complete response
+	Chat(ctx context.Context, model string, messages []Message, params Parameters) (*ChatResponse, error)
+
+	// ChatStream is like Chat but calls onDelta with every content fragment as it arrives
+	ChatStream(ctx context.Context, model string, messages []Message, params Parameters, onDelta func(string)) (*ChatResponse, error)
+}
+
+// WithSystemPrompt prepends a system message to the conversation.
+// An empty prompt leaves the conversation unchanged.
+func WithSystemPrompt(systemPrompt string, messages []Message) []Message {
+	if systemPrompt == "" {
+		return messages
+	}
+	return append([]Message{{Role: RoleSystem, Content: systemPrompt}}, messages...)
+}
+
+// Provider types selectable with provider_type in the config
+const (
+	TypeOpenAI    = "openai"    // OpenAI-compatible chat completions, the default
+	TypeAnthropic = "anthropic" // Anthropic Messages API
+	TypeGemini    = "gemini"    // Google Gemini generateContent API
+	TypeOllama    = "ollama"    // Native Ollama API
+)
+
+// ProviderTypes lists the supported provider types
+var ProviderTypes = []string{TypeOpenAI, TypeAnthropic, TypeGemini, TypeOllama}
+
+// Options configure a provider created by NewProvider
+type Options struct {
+	Type    string // One of ProviderTypes; empty means TypeOpenAI
+	BaseURL string
+	APIKey  string // May be empty for providers that need no key
+	Auth    Auth   // How the key is sent; zero uses the default of Type
+	Headers map[string]string
+	Query   map[string]string
+	Retry   RetryPolicy
+	OnRetry RetryNotifier
+	Timeout time.Duration // Idle timeout, see Client.SetTimeout
+}
+
+// NewProvider creates the provider for opts.Type.
+// All provider types share the HTTP transport and retry handling of Client.
+func NewProvider(opts Options) (Provider, error) {
+	switch opts.Type {
+	case "", TypeOpenAI, TypeAnthropic, TypeGemini, TypeOllama:
+	default:
+		return nil, fmt.Errorf(resources.ErrUnknownProviderType, opts.Type, strings.Join(ProviderTypes, ", "))
+	}
+
+	client, err := NewClient(opts.BaseURL, opts.APIKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.SetAuth(opts.Auth); err != nil {
+		return nil, err
+	}
+	client.SetHeaders(opts.Headers)
+	client.SetQuery(opts.Query)
+	client.SetRetryPolicy(opts.Retry)
+	client.SetRetryNotifier(opts.OnRetry)
+	client.SetTimeout(opts.Timeout)
+
+	switch opts.Type {
+	case TypeAnthropic:
+		return newAnthropicProvider(client), nil
+	case TypeGemini:
+		return newGeminiProvider(client), nil
+	case TypeOllama:
+		return newOllamaProvider(client), nil
+	}
+	return client, nil
+}
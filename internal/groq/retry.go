@@ -0,0 +1,93 @@
+package groq
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls how requests failing with 429 or 5xx are retried
+type RetryPolicy struct {
+	MaxAttempts  int           `mapstructure:"max_attempts"`  // Total attempts including the first one, 1 disables retries
+	InitialDelay time.Duration `mapstructure:"initial_delay"` // Backoff before the first retry, doubled on every attempt
+	MaxDelay     time.Duration `mapstructure:"max_delay"`     // Upper limit for a single wait
+}
+
+// RetryNotifier is called before a failed request is retried
+type RetryNotifier func(attempt, maxAttempts int, delay time.Duration, statusCode int)
+
+// DefaultRetryPolicy returns the policy used for unset fields
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:  3,
+		InitialDelay: time.Second,
+		MaxDelay:     30 * time.Second,
+	}
+}
+
+// withDefaults fills unset fields from DefaultRetryPolicy
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	defaults := DefaultRetryPolicy()
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaults.MaxAttempts
+	}
+	if p.InitialDelay <= 0 {
+		p.InitialDelay = defaults.InitialDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = defaults.MaxDelay
+	}
+	return p
+}
+
+// isRetryable reports whether a response status is worth retrying
+func isRetryable(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// delay returns how long to wait before the next attempt.
+// A delay requested by the server is honored; when it exceeds MaxDelay the
+// request is not retried and ok is false.
+func (p RetryPolicy) delay(attempt int, header http.Header) (d time.Duration, ok bool) {
+	if d, found := serverDelay(header); found {
+		return d, d <= p.MaxDelay
+	}
+
+	// Exponential backoff with equal jitter
+	backoff := p.InitialDelay << (attempt - 1)
+	if backoff <= 0 || backoff > p.MaxDelay {
+		backoff = p.MaxDelay
+	}
+	half := backoff / 2
+	return half + rand.N(half+1), true
+}
+
+// serverDelay reads the wait time from Retry-After or Groq's rate limit headers
+func serverDelay(header http.Header) (time.Duration, bool) {
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if at, err := http.ParseTime(value); err == nil {
+			return max(time.Until(at), 0), true
+		}
+	}
+
+	// Groq sends e.g. x-ratelimit-reset-tokens: 7.66s for every exhausted limit
+	var wait time.Duration
+	found := false
+	for _, limit := range []string{"requests", "tokens"} {
+		if header.Get("x-ratelimit-remaining-"+limit) != "0" {
+			continue
+		}
+		d, err := time.ParseDuration(header.Get("x-ratelimit-reset-" + limit))
+		if err != nil {
+			continue
+		}
+		wait = max(wait, d)
+		found = true
+	}
+	return wait, found
+}
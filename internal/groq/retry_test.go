@@ -0,0 +1,189 @@
+package groq
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestServerDelay(t *testing.T) {
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   time.Duration
+		found  bool
+	}{
+		{name: "none", header: nil, found: false},
+		{name: "retry-after seconds", header: map[string]string{"Retry-After": " 7 "}, want: 7 * time.Second, found: true},
+		{name: "retry-after past date", header: map[string]string{"Retry-After": "Mon, 02 Jan 2006 15:04:05 GMT"}, want: 0, found: true},
+		{name: "retry-after invalid", header: map[string]string{"Retry-After": "soon"}, found: false},
+		{
+			name:   "exhausted tokens",
+			header: map[string]string{"x-ratelimit-remaining-tokens": "0", "x-ratelimit-reset-tokens": "7.66s"},
+			want:   7660 * time.Millisecond,
+			found:  true,
+		},
+		{
+			name: "longest exhausted limit",
+			header: map[string]string{
+				"x-ratelimit-remaining-requests": "0", "x-ratelimit-reset-requests": "2m59.56s",
+				"x-ratelimit-remaining-tokens": "0", "x-ratelimit-reset-tokens": "1.5s",
+			},
+			want:  2*time.Minute + 59560*time.Millisecond,
+			found: true,
+		},
+		{
+			name:   "limit not exhausted",
+			header: map[string]string{"x-ratelimit-remaining-tokens": "12", "x-ratelimit-reset-tokens": "7s"},
+			found:  false,
+		},
+		{
+			name:   "retry-after wins",
+			header: map[string]string{"Retry-After": "3", "x-ratelimit-remaining-tokens": "0", "x-ratelimit-reset-tokens": "9s"},
+			want:   3 * time.Second,
+			found:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for name, value := range tt.header {
+				header.Set(name, value)
+			}
+			got, found := serverDelay(header)
+			if got != tt.want || found != tt.found {
+				t.Errorf("serverDelay = %v, %v; want %v, %v", got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestServerDelayDate(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", time.Now().Add(10*time.Second).UTC().Format(http.TimeFormat))
+	got, found := serverDelay(header)
+	if !found || got < 8*time.Second || got > 10*time.Second {
+		t.Errorf("serverDelay = %v, %v; want about 10s", got, found)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, InitialDelay: time.Second, MaxDelay: 4 * time.Second}
+
+	// Equal jitter keeps every wait between half and all of the backoff, capped at MaxDelay
+	for _, tt := range []struct {
+		attempt int
+		backoff time.Duration
+	}{{1, time.Second}, {2, 2 * time.Second}, {3, 4 * time.Second}, {4, 4 * time.Second}, {80, 4 * time.Second}} {
+		for range 20 {
+			d, ok := policy.delay(tt.attempt, http.Header{})
+			if !ok || d < tt.backoff/2 || d > tt.backoff {
+				t.Fatalf("attempt %d: delay = %v, %v; want between %v and %v", tt.attempt, d, ok, tt.backoff/2, tt.backoff)
+			}
+		}
+	}
+
+	// A server-requested wait is honored, but not beyond MaxDelay
+	header := http.Header{}
+	header.Set("Retry-After", "3")
+	if d, ok := policy.delay(1, header); d != 3*time.Second || !ok {
+		t.Errorf("delay = %v, %v; want the requested 3s", d, ok)
+	}
+	header.Set("Retry-After", "60")
+	if _, ok := policy.delay(1, header); ok {
+		t.Error("wait beyond MaxDelay accepted")
+	}
+}
+
+func TestRetryPolicyDefaults(t *testing.T) {
+	got := RetryPolicy{MaxAttempts: 1}.withDefaults()
+	want := DefaultRetryPolicy()
+	want.MaxAttempts = 1
+	if got != want {
+		t.Errorf("withDefaults = %+v, want %+v", got, want)
+	}
+}
+
+// retryServer returns a client for a server answering every request with
+// status and header, and the number of requests it received
+func retryServer(t *testing.T, status int, header map[string]string, policy RetryPolicy) (*Client, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		for name, value := range header {
+			w.Header().Set(name, value)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(`{"error":{"message":"try later"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL, "test-key")
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	client.SetRetryPolicy(policy)
+	return client, &requests
+}
+
+func TestRetries(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	tests := []struct {
+		name     string
+		status   int
+		header   map[string]string
+		attempts int32
+	}{
+		{name: "server error", status: http.StatusServiceUnavailable, attempts: 3},
+		{name: "rate limit", status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "0"}, attempts: 3},
+		{name: "client error", status: http.StatusBadRequest, attempts: 1},
+		{name: "wait too long", status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "60"}, attempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, requests := retryServer(t, tt.status, tt.header, policy)
+			var notified []int
+			client.SetRetryNotifier(func(attempt, maxAttempts int, delay time.Duration, statusCode int) {
+				if maxAttempts != 3 || statusCode != tt.status {
+					t.Errorf("notified of %d/%d with status %d", attempt, maxAttempts, statusCode)
+				}
+				notified = append(notified, attempt)
+			})
+
+			_, err := client.makeRequest(context.Background(), "GET", "models", nil)
+
+			// The error of the last attempt is returned once the attempts are used up
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Fatalf("error = %v, want an API error with status %d", err, tt.status)
+			}
+			if got := requests.Load(); got != tt.attempts {
+				t.Errorf("got %d requests, want %d", got, tt.attempts)
+			}
+			if len(notified) != int(tt.attempts)-1 {
+				t.Errorf("notified of retries %v, want %d", notified, tt.attempts-1)
+			}
+		})
+	}
+}
+
+func TestRetryCancelled(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialDelay: time.Hour, MaxDelay: time.Hour}
+	client, requests := retryServer(t, http.StatusServiceUnavailable, nil, policy)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client.SetRetryNotifier(func(int, int, time.Duration, int) { cancel() })
+
+	if _, err := client.makeRequest(ctx, "GET", "models", nil); err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Errorf("error = %v, want the cancellation", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+}
@@ -0,0 +1,113 @@
+package groq
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"groq-cli-chat/resources"
+)
+
+// ChatStream sends a streaming chat request to the Groq API.
+// onDelta is called with every content fragment as it arrives; the returned
+// response holds the assembled message and the usage reported by the API.
+// Cancelling ctx aborts the stream.
+func (c *Client) ChatStream(ctx context.Context, model string, messages []Message, params Parameters, onDelta func(string)) (*ChatResponse, error) {
+	// Start timing the request
+	startTime := time.Now()
+
+	payload := ChatRequest{
+		Model:         model,
+		Messages:      messages,
+		Parameters:    params,
+		Stream:        true,
+		StreamOptions: &StreamOptions{IncludeUsage: true},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf(resources.ErrEncodePayload, err)
+	}
+
+	resp, err := c.makeModelRequest(ctx, model, "POST", "chat/completions", body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	var usage Usage
+	var id string
+	var xGroq *XGroq
+	var timings *llamaTimings
+
+	scanner := bufio.NewScanner(resp.Body)
+	// Allow long chunks, the default 64KB token limit is too small for some providers
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			// Skip blank separators, comments and other SSE fields
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk chatStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf(resources.ErrDecodeResponse, err)
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			content.WriteString(choice.Delta.Content)
+			if onDelta != nil {
+				onDelta(choice.Delta.Content)
+			}
+		}
+
+		if chunk.ID != "" {
+			id = chunk.ID
+		}
+		if chunk.XGroq != nil {
+			if chunk.XGroq.ID != "" {
+				xGroq = &XGroq{ID: chunk.XGroq.ID}
+			}
+			if chunk.XGroq.Usage != nil {
+				usage = *chunk.XGroq.Usage
+			}
+		} else if chunk.Usage != nil {
+			usage = *chunk.Usage
+		}
+		if chunk.Timings != nil {
+			timings = chunk.Timings
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf(resources.ErrReadStream, err)
+	}
+
+	// Verify that we have received any content
+	if content.Len() == 0 {
+		return nil, fmt.Errorf(resources.ErrEmptyResponse)
+	}
+
+	chatResp := &ChatResponse{
+		ID:      id,
+		Choices: []Choice{{Message: Message{Role: RoleAssistant, Content: content.String()}}},
+		Usage:   usage,
+		XGroq:   xGroq,
+	}
+
+	// Calculate elapsed time if not provided by the API
+	timings.apply(&chatResp.Usage)
+	chatResp.Usage.fillTimes(time.Since(startTime).Seconds())
+
+	return chatResp, nil
+}
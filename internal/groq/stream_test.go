@@ -0,0 +1,135 @@
+package groq
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// sseServer returns a client for a server answering chat requests with the
+// canned SSE body. The decoded request is stored in req, if not nil.
+func sseServer(t *testing.T, body string, req *ChatRequest) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("Authorization = %q, want bearer token", got)
+		}
+		if req != nil {
+			if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+				t.Errorf("decode request: %v", err)
+			}
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL, "test-key")
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client
+}
+
+// stream runs ChatStream and returns the response and the deltas passed to onDelta
+func stream(t *testing.T, client *Client) (*ChatResponse, []string, error) {
+	t.Helper()
+	var deltas []string
+	resp, err := client.ChatStream(context.Background(), "test-model",
+		[]Message{{Role: RoleUser, Content: "hi"}}, Parameters{}, func(delta string) {
+			deltas = append(deltas, delta)
+		})
+	return resp, deltas, err
+}
+
+func TestChatStreamGroq(t *testing.T) {
+	body := `: keep-alive
+
+data: {"id":"chatcmpl-1","choices":[{"delta":{"role":"assistant","content":""}}]}
+
+data: {"id":"chatcmpl-1","choices":[{"delta":{"content":"Hel"}}]}
+
+event: ping
+data:{"id":"chatcmpl-1","choices":[{"delta":{"content":"lo"}}]}
+
+data: {"id":"chatcmpl-1","choices":[{"delta":{}}],"x_groq":{"id":"req_123","usage":{"prompt_tokens":7,"completion_tokens":2,"total_tokens":9,"queue_time":0.01,"completion_time":0.2,"total_time":0.25}}}
+
+data: [DONE]
+
+data: not read after done
+`
+	var req ChatRequest
+	resp, deltas, err := stream(t, sseServer(t, body, &req))
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+
+	if !req.Stream || req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+		t.Errorf("request does not ask for a stream with usage: %+v", req)
+	}
+	if strings.Join(deltas, "|") != "Hel|lo" {
+		t.Errorf("deltas = %q, want Hel and lo", deltas)
+	}
+	if got := resp.Choices[0].Message; got.Role != RoleAssistant || got.Content != "Hello" {
+		t.Errorf("message = %+v, want the assembled assistant message", got)
+	}
+	if resp.ID != "chatcmpl-1" || resp.RequestID() != "req_123" {
+		t.Errorf("IDs = %q and %q, want chatcmpl-1 and req_123", resp.ID, resp.RequestID())
+	}
+	if resp.Usage.TotalTokens != 9 || resp.Usage.CompletionTime != 0.2 || resp.Usage.QueueTime != 0.01 {
+		t.Errorf("usage = %+v, want the x_groq usage", resp.Usage)
+	}
+}
+
+func TestChatStreamUsage(t *testing.T) {
+	// OpenAI-compatible APIs report usage in a final chunk without choices
+	body := `data: {"id":"c2","choices":[{"delta":{"content":"ok"}}]}
+data: {"id":"c2","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}
+data: [DONE]
+`
+	resp, _, err := stream(t, sseServer(t, body, nil))
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	if resp.Usage.PromptTokens != 3 || resp.Usage.CompletionTokens != 1 || resp.Usage.TotalTokens != 4 {
+		t.Errorf("usage = %+v, want 3 + 1 tokens", resp.Usage)
+	}
+	// Times not reported by the API are filled in from the elapsed time
+	if resp.Usage.TotalTime <= 0 {
+		t.Errorf("total time not filled in: %+v", resp.Usage)
+	}
+}
+
+func TestChatStreamErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "malformed chunk",
+			body: "data: {\"choices\":[{\"delta\":{\"content\":\"a\"}}]}\ndata: {oops\n",
+			want: "decode",
+		},
+		{
+			name: "no content",
+			body: ": only a comment\ndata: [DONE]\n",
+			want: "empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := stream(t, sseServer(t, tt.body, nil))
+			if err == nil || !strings.Contains(strings.ToLower(err.Error()), tt.want) {
+				t.Errorf("error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -1,16 +1,141 @@
 package groq
 
+// Message roles used in chat completion requests
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message represents a single message in a chat conversation
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest is the payload of a chat completion request
+type ChatRequest struct {
+	Model    string    `json:"model"`
+	Messages []Message `json:"messages"`
+	Parameters
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+}
+
+// StreamOptions controls what is sent in a streamed response
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
 // ChatResponse represents the structure of a chat completion response
 type ChatResponse struct {
+	ID      string   `json:"id"`
+	Choices []Choice `json:"choices"`
+	Usage   Usage    `json:"usage"`
+	XGroq   *XGroq   `json:"x_groq,omitempty"`
+}
+
+// Choice represents a single completion choice of a chat response
+type Choice struct {
+	Message Message `json:"message"`
+}
+
+// XGroq holds Groq-specific response metadata
+type XGroq struct {
+	ID    string `json:"id"`
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// RequestID returns Groq's x_groq request id, or the completion id for other providers
+func (r *ChatResponse) RequestID() string {
+	if r.XGroq != nil && r.XGroq.ID != "" {
+		return r.XGroq.ID
+	}
+	return r.ID
+}
+
+// Usage represents token usage and timing statistics of a chat completion.
+// Times are in seconds; Groq reports all of them, other providers only token counts.
+type Usage struct {
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	PromptTime       float64 `json:"prompt_time"`
+	CompletionTime   float64 `json:"completion_time"`
+	QueueTime        float64 `json:"queue_time"`
+	TotalTime        float64 `json:"total_time"`
+}
+
+// OutputTokens returns the number of generated tokens.
+// It falls back to the total when the provider does not report a breakdown.
+func (u Usage) OutputTokens() int {
+	if u.CompletionTokens > 0 {
+		return u.CompletionTokens
+	}
+	return u.TotalTokens
+}
+
+// OutputTokensPerSecond returns the generation speed, excluding the prompt
+func (u Usage) OutputTokensPerSecond() float64 {
+	if u.CompletionTime <= 0 {
+		return 0
+	}
+	return float64(u.OutputTokens()) / u.CompletionTime
+}
+
+// Add accumulates the usage of another request, e.g. a later turn of a conversation
+func (u *Usage) Add(other Usage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	u.PromptTime += other.PromptTime
+	u.CompletionTime += other.CompletionTime
+	u.QueueTime += other.QueueTime
+	u.TotalTime += other.TotalTime
+}
+
+// fillTimes uses the measured request duration for times the API did not report
+func (u *Usage) fillTimes(elapsed float64) {
+	if u.CompletionTime <= 0 {
+		u.CompletionTime = elapsed
+	}
+	if u.TotalTime <= 0 {
+		u.TotalTime = elapsed
+	}
+}
+
+// llamaTimings holds the timings llama.cpp's server adds to a response, in milliseconds
+type llamaTimings struct {
+	PromptMS    float64 `json:"prompt_ms"`
+	PredictedMS float64 `json:"predicted_ms"`
+}
+
+// apply sets the prompt and completion times the usage does not report
+func (t *llamaTimings) apply(u *Usage) {
+	if t == nil {
+		return
+	}
+	if u.PromptTime <= 0 {
+		u.PromptTime = t.PromptMS / 1000
+	}
+	if u.CompletionTime <= 0 {
+		u.CompletionTime = t.PredictedMS / 1000
+	}
+}
+
+// chatStreamChunk represents a single server-sent event of a streamed chat completion.
+// Groq reports usage in x_groq on the last chunk, OpenAI-compatible APIs in usage
+// and llama.cpp also sends timings.
+type chatStreamChunk struct {
+	ID      string `json:"id"`
 	Choices []struct {
-		Message struct {
+		Delta struct {
 			Content string `json:"content"`
-		} `json:"message"`
+		} `json:"delta"`
 	} `json:"choices"`
-	Usage struct {
-		TotalTokens    int     `json:"total_tokens"`
-		CompletionTime float64 `json:"completion_time"`
-	} `json:"usage"`
+	Usage   *Usage        `json:"usage"`
+	XGroq   *XGroq        `json:"x_groq"`
+	Timings *llamaTimings `json:"timings"`
 }
 
 // ModelInfo represents the structure of a model retrieval response
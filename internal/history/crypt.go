@@ -0,0 +1,291 @@
+package history
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+	"groq-cli-chat/resources"
+)
+
+const (
+	keyFile         = "key.json"
+	rekeySuffix     = ".rekey" // Pending index and key of an interrupted Rekey
+	encryptedPrefix = "enc:"
+	checkText       = "groq-chat history"
+)
+
+// ErrWrongPassphrase is returned by Open when the passphrase does not match the key file
+var ErrWrongPassphrase = errors.New(resources.ErrWrongPassphrase)
+
+// Encryption configures the encrypted history store in config.yaml
+type Encryption struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	PassphraseEnv string `mapstructure:"passphrase_env"` // Variable holding the passphrase, asked for on the terminal otherwise
+}
+
+// keyParams are the key derivation parameters stored next to an encrypted index.
+// Check holds a known text encrypted with the key to detect wrong passphrases.
+type keyParams struct {
+	KDF   string `json:"kdf"`
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	Salt  []byte `json:"salt"`
+	Check string `json:"check"`
+}
+
+// newKeyParams returns scrypt parameters with a fresh salt
+func newKeyParams() (keyParams, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return keyParams{}, err
+	}
+	return keyParams{KDF: "scrypt", N: 1 << 15, R: 8, P: 1, Salt: salt}, nil
+}
+
+// newAEAD derives the AES-256-GCM cipher for a passphrase
+func (p keyParams) newAEAD(passphrase string) (cipher.AEAD, error) {
+	if p.KDF != "scrypt" {
+		return nil, fmt.Errorf(resources.ErrHistoryKeyFile, "unsupported kdf "+p.KDF)
+	}
+	key, err := scrypt.Key([]byte(passphrase), p.Salt, p.N, p.R, p.P, 32)
+	if err != nil {
+		return nil, fmt.Errorf(resources.ErrHistoryKeyFile, err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts data into a single index line
+func seal(aead cipher.AEAD, data []byte) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, data, nil)), nil
+}
+
+// unseal decrypts a line written by seal
+func unseal(aead cipher.AEAD, line string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, encryptedPrefix))
+	if err != nil || len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf(resources.ErrDecryptHistory)
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf(resources.ErrDecryptHistory)
+	}
+	return plain, nil
+}
+
+// Seal encrypts data with the key of the store, e.g. for files kept next to the history
+func (s *Store) Seal(data []byte) ([]byte, error) {
+	if s.aead == nil {
+		return nil, fmt.Errorf(resources.ErrHistoryNotEncrypted)
+	}
+	sealed, err := seal(s.aead, data)
+	return []byte(sealed), err
+}
+
+// Unseal decrypts data written by Seal
+func (s *Store) Unseal(data []byte) ([]byte, error) {
+	if s.aead == nil {
+		return nil, fmt.Errorf(resources.ErrHistoryLocked)
+	}
+	return unseal(s.aead, string(data))
+}
+
+// IsEncrypted reports whether the history in dir, or in the default directory, is encrypted
+func IsEncrypted(dir string) bool {
+	if dir == "" {
+		var err error
+		if dir, err = DefaultDir(); err != nil {
+			return false
+		}
+	}
+	return (&Store{dir: dir}).isEncrypted()
+}
+
+// isEncrypted reports whether the store has a key file, i.e. holds encrypted records
+func (s *Store) isEncrypted() bool {
+	_, err := os.Stat(filepath.Join(s.dir, keyFile))
+	return err == nil
+}
+
+// unlock derives the key from the passphrase, creating the key file for a new
+// encrypted store. It fails with ErrWrongPassphrase for a wrong passphrase.
+func (s *Store) unlock(passphrase string) error {
+	data, err := os.ReadFile(filepath.Join(s.dir, keyFile))
+	if os.IsNotExist(err) {
+		return s.writeKey(passphrase)
+	}
+	if err != nil {
+		return fmt.Errorf(resources.ErrHistoryKeyFile, err)
+	}
+
+	var params keyParams
+	if err := json.Unmarshal(data, &params); err != nil {
+		return fmt.Errorf(resources.ErrHistoryKeyFile, err)
+	}
+	aead, err := params.newAEAD(passphrase)
+	if err != nil {
+		return err
+	}
+	if check, err := unseal(aead, params.Check); err != nil || string(check) != checkText {
+		return ErrWrongPassphrase
+	}
+
+	s.aead = aead
+	return nil
+}
+
+// newKey derives a new key for the passphrase and returns it with its encoded parameters
+func newKey(passphrase string) (cipher.AEAD, []byte, error) {
+	if passphrase == "" {
+		return nil, nil, fmt.Errorf(resources.ErrEmptyPassphrase)
+	}
+	params, err := newKeyParams()
+	if err != nil {
+		return nil, nil, err
+	}
+	aead, err := params.newAEAD(passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	if params.Check, err = seal(aead, []byte(checkText)); err != nil {
+		return nil, nil, err
+	}
+	data, err := json.MarshalIndent(params, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return aead, data, nil
+}
+
+// writeKey sets up a new key for the passphrase and saves its parameters
+func (s *Store) writeKey(passphrase string) error {
+	aead, data, err := newKey(passphrase)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf(resources.ErrCreateHistoryDir, err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dir, keyFile), data); err != nil {
+		return fmt.Errorf(resources.ErrHistoryKeyFile, err)
+	}
+
+	s.aead = aead
+	return nil
+}
+
+// encryptAll rewrites plaintext records encrypted and removes their Markdown
+// files, e.g. after encryption was turned on for an existing history
+func (s *Store) encryptAll() error {
+	records, plaintext, err := s.load()
+	if err != nil || !plaintext {
+		return err
+	}
+	for _, rec := range records {
+		if rec.MarkdownFile != "" {
+			os.Remove(filepath.Join(s.dir, rec.MarkdownFile))
+			rec.MarkdownFile = ""
+		}
+	}
+	if err := s.rewrite(records); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, resources.InfoHistoryEncrypted, len(records))
+	return nil
+}
+
+// Rekey re-encrypts the history with a new passphrase.
+// The new index and key are written next to the current ones first and then
+// swapped in, index first; recoverRekey completes or discards an interrupted swap.
+func (s *Store) Rekey(passphrase string) error {
+	if s.aead == nil {
+		return fmt.Errorf(resources.ErrHistoryNotEncrypted)
+	}
+	records, err := s.List()
+	if err != nil {
+		return err
+	}
+
+	aead, key, err := newKey(passphrase)
+	if err != nil {
+		return err
+	}
+	old := s.aead
+	s.aead = aead
+	indexPath := filepath.Join(s.dir, indexFile)
+	keyPath := filepath.Join(s.dir, keyFile)
+
+	// The pending index is complete once it exists, the pending key is written last
+	err = s.writeIndex(records, indexFile+rekeySuffix)
+	if err == nil {
+		err = writeFileAtomic(keyPath+rekeySuffix, key)
+	}
+	if err != nil {
+		s.aead = old
+		os.Remove(indexPath + rekeySuffix)
+		return err
+	}
+
+	if err := os.Rename(indexPath+rekeySuffix, indexPath); err != nil {
+		s.aead = old
+		os.Remove(indexPath + rekeySuffix)
+		os.Remove(keyPath + rekeySuffix)
+		return err
+	}
+	if err := os.Rename(keyPath+rekeySuffix, keyPath); err != nil {
+		return fmt.Errorf(resources.ErrHistoryKeyFile, err)
+	}
+	return nil
+}
+
+// recoverRekey finishes a Rekey interrupted after the new index was swapped in,
+// or discards its pending files if the old index is still in place
+func (s *Store) recoverRekey() error {
+	indexPath := filepath.Join(s.dir, indexFile)
+	keyPath := filepath.Join(s.dir, keyFile)
+
+	if _, err := os.Stat(indexPath + rekeySuffix); err == nil {
+		os.Remove(keyPath + rekeySuffix)
+		return os.Remove(indexPath + rekeySuffix)
+	}
+	if _, err := os.Stat(keyPath + rekeySuffix); err == nil {
+		if err := os.Rename(keyPath+rekeySuffix, keyPath); err != nil {
+			return fmt.Errorf(resources.ErrHistoryKeyFile, err)
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic replaces a private file via a temporary file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
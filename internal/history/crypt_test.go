@@ -0,0 +1,216 @@
+package history
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"groq-cli-chat/internal/groq"
+	"groq-cli-chat/resources"
+)
+
+// openEncrypted opens an encrypted store in dir with the passphrase
+func openEncrypted(dir, passphrase string) (*Store, error) {
+	return Open(Options{Dir: dir, Markdown: true, Encrypt: true, Passphrase: func(bool) (string, error) {
+		return passphrase, nil
+	}})
+}
+
+// saveSecret saves a record holding a prompt that must not appear on disk
+func saveSecret(t *testing.T, store *Store) *Record {
+	t.Helper()
+	rec := &Record{Model: "test-model", Messages: []groq.Message{
+		{Role: groq.RoleUser, Content: "the launch code is 1234"},
+		{Role: groq.RoleAssistant, Content: "noted"},
+	}}
+	if err := store.Save(rec); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return rec
+}
+
+// readFile returns the content of a file in dir
+func readFile(t *testing.T, dir, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("read %s: %v", name, err)
+	}
+	return data
+}
+
+// writeFile replaces a file in dir
+func writeFile(t *testing.T, dir, name string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+}
+
+// assertReadable checks that the store opens with the passphrase and holds the record
+func assertReadable(t *testing.T, dir, passphrase string, want *Record) {
+	t.Helper()
+	store, err := openEncrypted(dir, passphrase)
+	if err != nil {
+		t.Fatalf("open with %q: %v", passphrase, err)
+	}
+	records, err := store.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(records) != 1 || records[0].ID != want.ID || records[0].Prompt() != want.Prompt() {
+		t.Fatalf("records = %+v, want the saved record", records)
+	}
+}
+
+func TestEncryptedRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	var confirmed bool
+	store, err := Open(Options{Dir: dir, Markdown: true, Encrypt: true, Passphrase: func(confirm bool) (string, error) {
+		confirmed = confirm
+		return "correct horse", nil
+	}})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if !confirmed || !store.Encrypted() {
+		t.Errorf("confirm = %v, encrypted = %v; want a confirmed new key", confirmed, store.Encrypted())
+	}
+	rec := saveSecret(t, store)
+
+	// Neither the index nor a Markdown copy holds the plaintext
+	index := readFile(t, dir, indexFile)
+	if !bytes.HasPrefix(index, []byte(encryptedPrefix)) || bytes.Contains(index, []byte("launch code")) {
+		t.Errorf("index is not encrypted: %s", index)
+	}
+	if rec.MarkdownFile != "" {
+		t.Errorf("Markdown file %s written for an encrypted store", rec.MarkdownFile)
+	}
+	if !IsEncrypted(dir) {
+		t.Error("IsEncrypted = false, want true")
+	}
+
+	assertReadable(t, dir, "correct horse", rec)
+
+	sealed, err := store.Seal([]byte("session data"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if plain, err := store.Unseal(sealed); err != nil || string(plain) != "session data" {
+		t.Errorf("Unseal = %q, %v; want the sealed data", plain, err)
+	}
+}
+
+func TestWrongPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	store, err := openEncrypted(dir, "correct horse")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	saveSecret(t, store)
+
+	if _, err := openEncrypted(dir, "battery staple"); err == nil || err.Error() != resources.ErrWrongPassphrase {
+		t.Errorf("open with a wrong passphrase: %v, want %q", err, resources.ErrWrongPassphrase)
+	}
+	// An encrypted history stays locked without a passphrase, whatever the config says
+	if _, err := Open(Options{Dir: dir}); err == nil || err.Error() != resources.ErrHistoryLocked {
+		t.Errorf("open without a passphrase: %v, want %q", err, resources.ErrHistoryLocked)
+	}
+	if _, err := openEncrypted(t.TempDir(), ""); err == nil || err.Error() != resources.ErrEmptyPassphrase {
+		t.Errorf("new key with an empty passphrase: %v, want %q", err, resources.ErrEmptyPassphrase)
+	}
+}
+
+func TestEncryptExisting(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(Options{Dir: dir, Markdown: true})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	rec := saveSecret(t, store)
+	if rec.MarkdownFile == "" {
+		t.Fatal("no Markdown file written for a plaintext store")
+	}
+
+	assertReadable(t, dir, "correct horse", rec)
+	if bytes.Contains(readFile(t, dir, indexFile), []byte("launch code")) {
+		t.Error("plaintext records left in the index")
+	}
+	if _, err := os.Stat(filepath.Join(dir, rec.MarkdownFile)); !os.IsNotExist(err) {
+		t.Errorf("Markdown copy kept after encryption: %v", err)
+	}
+}
+
+func TestRekey(t *testing.T) {
+	dir := t.TempDir()
+	store, err := openEncrypted(dir, "old passphrase")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	rec := saveSecret(t, store)
+
+	if err := store.Rekey("new passphrase"); err != nil {
+		t.Fatalf("Rekey: %v", err)
+	}
+	assertReadable(t, dir, "new passphrase", rec)
+	if _, err := openEncrypted(dir, "old passphrase"); err == nil {
+		t.Error("old passphrase still accepted after Rekey")
+	}
+	for _, name := range []string{indexFile + rekeySuffix, keyFile + rekeySuffix} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s left behind: %v", name, err)
+		}
+	}
+}
+
+// TestRekeyInterrupted recreates the files left by a Rekey interrupted
+// before and after the new index was swapped in
+func TestRekeyInterrupted(t *testing.T) {
+	tests := []struct {
+		name       string
+		swapped    bool   // Whether the new index replaced the old one
+		passphrase string // Passphrase that opens the recovered store
+	}{
+		{name: "before index swap", swapped: false, passphrase: "old passphrase"},
+		{name: "after index swap", swapped: true, passphrase: "new passphrase"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			store, err := openEncrypted(dir, "old passphrase")
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			rec := saveSecret(t, store)
+			oldIndex, oldKey := readFile(t, dir, indexFile), readFile(t, dir, keyFile)
+
+			if err := store.Rekey("new passphrase"); err != nil {
+				t.Fatalf("Rekey: %v", err)
+			}
+			newIndex, newKey := readFile(t, dir, indexFile), readFile(t, dir, keyFile)
+
+			// The old key is always in place, the new one pending
+			writeFile(t, dir, keyFile, oldKey)
+			writeFile(t, dir, keyFile+rekeySuffix, newKey)
+			if tt.swapped {
+				writeFile(t, dir, indexFile, newIndex)
+			} else {
+				writeFile(t, dir, indexFile, oldIndex)
+				writeFile(t, dir, indexFile+rekeySuffix, newIndex)
+			}
+
+			assertReadable(t, dir, tt.passphrase, rec)
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, entry := range entries {
+				if strings.HasSuffix(entry.Name(), rekeySuffix) {
+					t.Errorf("%s left behind after recovery", entry.Name())
+				}
+			}
+		})
+	}
+}
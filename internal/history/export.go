@@ -0,0 +1,171 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"groq-cli-chat/internal/groq"
+	"groq-cli-chat/resources"
+)
+
+// Export formats
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatJSON     = "json"
+	FormatJSONL    = "jsonl" // OpenAI fine-tuning dataset, one {"messages":[...]} per line
+)
+
+// ExportFormats lists the supported export formats
+var ExportFormats = []string{FormatMarkdown, FormatHTML, FormatJSON, FormatJSONL}
+
+// FormatForFile guesses the export format from a file extension, defaulting to Markdown
+func FormatForFile(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".html", ".htm":
+		return FormatHTML
+	case ".json":
+		return FormatJSON
+	case ".jsonl":
+		return FormatJSONL
+	default:
+		return FormatMarkdown
+	}
+}
+
+// Export writes records to w in the given format
+func Export(w io.Writer, records []*Record, format string) error {
+	switch strings.ToLower(format) {
+	case FormatMarkdown, "md":
+		return exportMarkdown(w, records)
+	case FormatHTML:
+		return exportHTML(w, records)
+	case FormatJSON:
+		return exportJSON(w, records)
+	case FormatJSONL:
+		return exportFineTuning(w, records)
+	default:
+		return fmt.Errorf(resources.ErrUnknownExportFormat, format, strings.Join(ExportFormats, ", "))
+	}
+}
+
+// exportMarkdown writes a single report with the full conversation of every record
+func exportMarkdown(w io.Writer, records []*Record) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, resources.ExportMarkdownHeader, time.Now().Format("2006-01-02 15:04"), len(records))
+
+	for _, rec := range records {
+		params := rec.Parameters.String()
+		if params == "" {
+			params = resources.DefaultParameters
+		}
+		fmt.Fprintf(&b, resources.ExportMarkdownEntry, rec.Time.Format("2006-01-02 15:04:05"), rec.Model, rec.ID, params)
+
+		for _, msg := range rec.Messages {
+			fmt.Fprintf(&b, resources.ExportMarkdownMessage, RoleTitle(msg.Role), msg.Content)
+		}
+		fmt.Fprintf(&b, resources.ExportMarkdownStats,
+			rec.Usage.TotalTokens, rec.Usage.PromptTokens, rec.Usage.OutputTokens(),
+			rec.Usage.TotalTime, rec.Usage.OutputTokensPerSecond())
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// exportJSON writes the records as an indented JSON array
+func exportJSON(w io.Writer, records []*Record) error {
+	if records == nil {
+		records = []*Record{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+// exportFineTuning writes one {"messages":[...]} line per record.
+// Records without a response are skipped since they can't serve as examples.
+func exportFineTuning(w io.Writer, records []*Record) error {
+	enc := json.NewEncoder(w)
+	for _, rec := range records {
+		if rec.Response() == "" {
+			continue
+		}
+		example := struct {
+			Messages []groq.Message `json:"messages"`
+		}{Messages: rec.Messages}
+		if err := enc.Encode(example); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportHTML writes a self-contained page without external resources
+func exportHTML(w io.Writer, records []*Record) error {
+	return htmlReport.Execute(w, struct {
+		Exported string
+		Records  []*Record
+	}{
+		Exported: time.Now().Format("2006-01-02 15:04"),
+		Records:  records,
+	})
+}
+
+// RoleTitle returns the heading used for a message role
+func RoleTitle(role string) string {
+	switch role {
+	case groq.RoleSystem:
+		return "System"
+	case groq.RoleUser:
+		return "User"
+	case groq.RoleAssistant:
+		return "Response"
+	default:
+		return role
+	}
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"role": RoleTitle,
+	"time": func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Chat History</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 56rem; margin: 2rem auto; padding: 0 1rem; color: #222; background: #fafafa; }
+header p, .meta, .stats { color: #666; font-size: 0.9rem; }
+article { background: #fff; border: 1px solid #ddd; border-radius: 8px; padding: 1rem 1.5rem; margin-bottom: 1.5rem; }
+h2 { font-size: 1.1rem; margin: 0 0 0.25rem; }
+.message { margin-top: 1rem; }
+.message h3 { font-size: 0.8rem; text-transform: uppercase; letter-spacing: 0.05em; color: #888; margin: 0 0 0.25rem; }
+.message pre { white-space: pre-wrap; word-wrap: break-word; font-family: inherit; margin: 0; }
+.assistant pre { background: #f3f6fa; padding: 0.75rem; border-radius: 6px; }
+.system pre { color: #666; font-style: italic; }
+</style>
+</head>
+<body>
+<header>
+<h1>Chat History</h1>
+<p>Exported {{.Exported}} · entries: {{len .Records}}</p>
+</header>
+{{range .Records}}<article id="{{.ID}}">
+<h2>{{time .Time}} · {{.Model}}</h2>
+<div class="meta">{{.ID}}{{if .Provider}} · {{.Provider}}{{end}}{{with .Parameters.String}} · {{.}}{{end}}</div>
+{{range .Messages}}<section class="message {{.Role}}">
+<h3>{{role .Role}}</h3>
+<pre>{{.Content}}</pre>
+</section>
+{{end}}<p class="stats">{{.Usage.TotalTokens}} tokens ({{.Usage.PromptTokens}} prompt + {{.Usage.OutputTokens}} completion){{with .RequestID}} · request {{.}}{{end}}</p>
+</article>
+{{end}}</body>
+</html>
+`))
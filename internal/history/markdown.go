@@ -0,0 +1,151 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"groq-cli-chat/internal/groq"
+	"groq-cli-chat/resources"
+)
+
+// RenderMarkdown formats a record with resources.HistoryFormat, with every
+// message of the conversation after the system prompt
+func RenderMarkdown(rec *Record) string {
+	params := rec.Parameters.String()
+	if params == "" {
+		params = resources.DefaultParameters
+	}
+	systemPrompt := rec.SystemPrompt
+	if systemPrompt == "" {
+		systemPrompt = resources.NoSystemPrompt
+	}
+	var messages strings.Builder
+	for _, msg := range rec.History() {
+		fmt.Fprintf(&messages, resources.HistoryMessageFormat, RoleTitle(msg.Role), msg.Content)
+	}
+
+	return fmt.Sprintf(resources.HistoryFormat,
+		rec.Time.Format(timestampLayout), rec.Model, params, systemPrompt, messages.String(),
+		rec.Usage.PromptTokens, rec.Usage.OutputTokens(), rec.Usage.TotalTokens,
+		rec.Usage.QueueTime, rec.Usage.PromptTime, rec.Usage.CompletionTime, rec.Usage.TotalTime,
+		rec.Usage.OutputTokensPerSecond(), rec.RequestID)
+}
+
+// legacyFile matches Markdown files written before the JSONL index existed
+var legacyFile = regexp.MustCompile(`^chat_(\d{8}_\d{6})\.md$`)
+
+// migrate imports legacy Markdown files into a new index.
+// It does nothing once the index exists.
+func (s *Store) migrate() error {
+	if _, err := os.Stat(filepath.Join(s.dir, indexFile)); !os.IsNotExist(err) {
+		return nil
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf(resources.ErrReadHistoryDir, err)
+	}
+
+	var records []*Record
+	for _, entry := range entries {
+		match := legacyFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf(resources.ErrReadHistoryFile, err)
+		}
+		rec, err := parseMarkdown(string(data))
+		if err != nil {
+			// Keep unparsable files as they are, they are still readable by hand
+			continue
+		}
+
+		rec.ID = match[1]
+		rec.Time, _ = time.ParseInLocation(timestampLayout, match[1], time.Local)
+		rec.MarkdownFile = entry.Name()
+		records = append(records, rec)
+	}
+
+	if len(records) == 0 {
+		return nil
+	}
+	return s.rewrite(records)
+}
+
+// parseMarkdown reads a record back from resources.HistoryFormat.
+// Files written by older versions lack some fields, which are left empty.
+func parseMarkdown(content string) (*Record, error) {
+	prompt := markdownField(content, "**User**: ", "\n**Response**: ")
+	if prompt == "" {
+		return nil, fmt.Errorf(resources.ErrParseHistoryFile, "no prompt found")
+	}
+
+	rec := &Record{
+		Model:        markdownField(content, "**Model**: ", "\n"),
+		SystemPrompt: markdownField(content, "**System**: ", "\n**User**: "),
+	}
+	if rec.SystemPrompt == resources.NoSystemPrompt {
+		rec.SystemPrompt = ""
+	}
+
+	if rec.SystemPrompt != "" {
+		rec.Messages = append(rec.Messages, groq.Message{Role: groq.RoleSystem, Content: rec.SystemPrompt})
+	}
+	rec.Messages = append(rec.Messages,
+		groq.Message{Role: groq.RoleUser, Content: prompt},
+		groq.Message{Role: groq.RoleAssistant, Content: markdownField(content, "**Response**: ", "\n**Stats**:")},
+	)
+
+	// Stats are "- Name: value [seconds]" lines
+	stats := markdownField(content, "**Stats**:\n", "\x00")
+	for _, line := range strings.Split(stats, "\n") {
+		name, value, found := strings.Cut(strings.TrimPrefix(line, "- "), ": ")
+		if !found {
+			continue
+		}
+		value = strings.TrimSuffix(value, " seconds")
+		number, _ := strconv.ParseFloat(value, 64)
+
+		switch name {
+		case "Prompt Tokens":
+			rec.Usage.PromptTokens = int(number)
+		case "Completion Tokens":
+			rec.Usage.CompletionTokens = int(number)
+		case "Total Tokens":
+			rec.Usage.TotalTokens = int(number)
+		case "Queue Time":
+			rec.Usage.QueueTime = number
+		case "Prompt Time":
+			rec.Usage.PromptTime = number
+		case "Completion Time":
+			rec.Usage.CompletionTime = number
+		case "Total Time":
+			rec.Usage.TotalTime = number
+		case "Request ID":
+			rec.RequestID = value
+		}
+	}
+
+	return rec, nil
+}
+
+// markdownField returns the text between start and the following end marker
+func markdownField(content, start, end string) string {
+	_, rest, found := strings.Cut(content, start)
+	if !found {
+		return ""
+	}
+	value, _, _ := strings.Cut(rest, end)
+	return value
+}
@@ -0,0 +1,84 @@
+package history
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"groq-cli-chat/internal/groq"
+)
+
+// Record is a saved prompt/response exchange, or a whole conversation when
+// later turns are appended to it
+type Record struct {
+	ID           string          `json:"id"`
+	Time         time.Time       `json:"time"`
+	Updated      time.Time       `json:"updated,omitzero"`
+	Provider     string          `json:"provider,omitempty"`
+	Model        string          `json:"model"`
+	ConfigPath   string          `json:"config_path,omitempty"`
+	SystemPrompt string          `json:"system_prompt,omitempty"`
+	Parameters   groq.Parameters `json:"parameters"`
+	Messages     []groq.Message  `json:"messages"` // Everything sent, followed by the response
+	Usage        groq.Usage      `json:"usage"`    // Summed over all turns
+	RequestID    string          `json:"request_id,omitempty"`
+	MarkdownFile string          `json:"markdown_file,omitempty"` // Rendered copy in the history directory
+}
+
+// LastActive returns when the record was last updated, or saved if it never was
+func (r *Record) LastActive() time.Time {
+	if r.Updated.IsZero() {
+		return r.Time
+	}
+	return r.Updated
+}
+
+// Prompt returns the last user message of the exchange
+func (r *Record) Prompt() string {
+	return r.lastMessage(groq.RoleUser)
+}
+
+// History returns the conversation without the system prompt, ready to be
+// sent again with a new prompt
+func (r *Record) History() []groq.Message {
+	var messages []groq.Message
+	for _, msg := range r.Messages {
+		if msg.Role != groq.RoleSystem {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// Turns returns the number of prompts in the conversation
+func (r *Record) Turns() int {
+	turns := 0
+	for _, msg := range r.Messages {
+		if msg.Role == groq.RoleUser {
+			turns++
+		}
+	}
+	return turns
+}
+
+// Response returns the assistant's answer
+func (r *Record) Response() string {
+	return r.lastMessage(groq.RoleAssistant)
+}
+
+func (r *Record) lastMessage(role string) string {
+	for i := len(r.Messages) - 1; i >= 0; i-- {
+		if r.Messages[i].Role == role {
+			return r.Messages[i].Content
+		}
+	}
+	return ""
+}
+
+// newID returns a unique, chronologically sortable record ID,
+// e.g. 20250102_150405_9f3a1c
+func newID(t time.Time) string {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return t.Format(timestampLayout) + "_" + hex.EncodeToString(suffix)
+}
@@ -0,0 +1,162 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"groq-cli-chat/resources"
+)
+
+// Retention limits the size of the history, as set in the config. Empty or
+// zero values mean no limit.
+type Retention struct {
+	MaxAge     string `mapstructure:"max_age"`     // e.g. 90d or 720h
+	MaxEntries int    `mapstructure:"max_entries"` // Number of records to keep
+	MaxSize    string `mapstructure:"max_size"`    // Total size, e.g. 50MB
+}
+
+// Policy is a parsed retention setting
+type Policy struct {
+	MaxAge     time.Duration
+	MaxEntries int
+	MaxSize    int64 // Bytes
+}
+
+// IsZero reports whether the policy has no limits
+func (p Policy) IsZero() bool {
+	return p.MaxAge <= 0 && p.MaxEntries <= 0 && p.MaxSize <= 0
+}
+
+// Policy parses the retention settings
+func (r Retention) Policy() (Policy, error) {
+	var p Policy
+	var err error
+	if r.MaxAge != "" {
+		if p.MaxAge, err = ParseAge(r.MaxAge); err != nil {
+			return Policy{}, err
+		}
+	}
+	if r.MaxSize != "" {
+		if p.MaxSize, err = ParseSize(r.MaxSize); err != nil {
+			return Policy{}, err
+		}
+	}
+	p.MaxEntries = r.MaxEntries
+	return p, nil
+}
+
+// ParseSize parses a size in bytes with an optional KB, MB or GB suffix (powers of 1024)
+func ParseSize(value string) (int64, error) {
+	text := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1}} {
+		if rest, ok := strings.CutSuffix(text, unit.suffix); ok {
+			text, multiplier = strings.TrimSpace(rest), unit.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf(resources.ErrInvalidSize, value)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// FormatSize formats a size in bytes for display
+func FormatSize(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
+
+// Size returns the disk space used by a record: its index line, encrypted if
+// the store is, and its Markdown file
+func (s *Store) Size(rec *Record) int64 {
+	line, err := s.encode(rec)
+	size := int64(len(line) + 1)
+	if err != nil {
+		size = 0
+	}
+	if rec.MarkdownFile != "" {
+		if info, err := os.Stat(filepath.Join(s.dir, rec.MarkdownFile)); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// Prune removes the least recently active records exceeding the policy and
+// returns them with the disk space they used. A conversation continued lately
+// counts as recent however old its first turn is. With dryRun nothing is
+// removed, the records that would be are returned.
+func (s *Store) Prune(p Policy, dryRun bool) ([]*Record, int64, error) {
+	if p.IsZero() {
+		return nil, 0, nil
+	}
+
+	records, err := s.List()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Sorted by last activity, everything pruned is a prefix
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].LastActive().Before(records[j].LastActive())
+	})
+	cut := 0
+	if p.MaxAge > 0 {
+		cutoff := time.Now().Add(-p.MaxAge)
+		for cut < len(records) && records[cut].LastActive().Before(cutoff) {
+			cut++
+		}
+	}
+	if p.MaxEntries > 0 && len(records)-cut > p.MaxEntries {
+		cut = len(records) - p.MaxEntries
+	}
+	// Sizes are measured once, before the Markdown files are removed
+	sizes := make([]int64, len(records))
+	sizeOf := func(i int) int64 {
+		if sizes[i] == 0 {
+			sizes[i] = s.Size(records[i])
+		}
+		return sizes[i]
+	}
+	if p.MaxSize > 0 {
+		var total int64
+		for i := cut; i < len(records); i++ {
+			total += sizeOf(i)
+		}
+		for cut < len(records) && total > p.MaxSize {
+			total -= sizeOf(cut)
+			cut++
+		}
+	}
+
+	pruned := records[:cut]
+	var size int64
+	ids := make([]string, len(pruned))
+	for i, rec := range pruned {
+		size += sizeOf(i)
+		ids[i] = rec.ID
+	}
+	if dryRun || len(pruned) == 0 {
+		return pruned, size, nil
+	}
+	return pruned, size, s.Delete(ids...)
+}
@@ -0,0 +1,158 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"groq-cli-chat/internal/groq"
+)
+
+// pruneStore returns a store with three records, from least to most recently
+// active: "old", "recent", and "continued", which was started as long ago as
+// "old" but continued an hour ago
+func pruneStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := Open(Options{Dir: t.TempDir(), Markdown: true})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	now := time.Now()
+	for _, rec := range []*Record{
+		{ID: "old", Time: now.Add(-100 * 24 * time.Hour)},
+		{ID: "continued", Time: now.Add(-100 * 24 * time.Hour), Updated: now.Add(-time.Hour)},
+		{ID: "recent", Time: now.Add(-24 * time.Hour)},
+	} {
+		rec.Model = "test-model"
+		rec.Messages = []groq.Message{{Role: groq.RoleUser, Content: "prompt of " + rec.ID}, {Role: groq.RoleAssistant, Content: "answer"}}
+		if err := store.Save(rec); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	return store
+}
+
+// ids returns the IDs of records
+func ids(records []*Record) string {
+	var ids []string
+	for _, rec := range records {
+		ids = append(ids, rec.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestPrune(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy func(store *Store) Policy
+		pruned string
+	}{
+		{
+			// Age is measured from the last activity
+			name:   "age",
+			policy: func(*Store) Policy { return Policy{MaxAge: 30 * 24 * time.Hour} },
+			pruned: "old",
+		},
+		{
+			name:   "entries",
+			policy: func(*Store) Policy { return Policy{MaxEntries: 1} },
+			pruned: "old,recent",
+		},
+		{
+			name: "size",
+			policy: func(store *Store) Policy {
+				// Room for the two most recently active records only
+				records, _ := store.List()
+				var size int64
+				for _, rec := range records[1:] {
+					size += store.Size(rec)
+				}
+				return Policy{MaxSize: size}
+			},
+			pruned: "old",
+		},
+		{
+			name:   "within limits",
+			policy: func(*Store) Policy { return Policy{MaxAge: 365 * 24 * time.Hour, MaxEntries: 3, MaxSize: 1 << 20} },
+			pruned: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := pruneStore(t)
+			policy := tt.policy(store)
+			before, err := store.List()
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			var want int64
+			for _, rec := range before {
+				if strings.Contains(","+tt.pruned+",", ","+rec.ID+",") {
+					want += store.Size(rec)
+				}
+			}
+
+			// A dry run reports the same records and size without removing anything
+			dryPruned, drySize, err := store.Prune(policy, true)
+			if err != nil {
+				t.Fatalf("Prune dry run: %v", err)
+			}
+			if records, _ := store.List(); len(records) != len(before) {
+				t.Errorf("dry run removed %d records", len(before)-len(records))
+			}
+
+			pruned, size, err := store.Prune(policy, false)
+			if err != nil {
+				t.Fatalf("Prune: %v", err)
+			}
+			if ids(pruned) != tt.pruned || ids(dryPruned) != tt.pruned {
+				t.Errorf("pruned %q, dry run %q; want %q", ids(pruned), ids(dryPruned), tt.pruned)
+			}
+			// The size is measured before the Markdown files are removed
+			if size != want || drySize != want {
+				t.Errorf("size = %d, dry run %d; want %d", size, drySize, want)
+			}
+
+			records, err := store.List()
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			if len(records)+len(pruned) != len(before) {
+				t.Errorf("%d records left, want %d", len(records), len(before)-len(pruned))
+			}
+			for _, rec := range pruned {
+				if _, err := os.Stat(filepath.Join(store.Dir(), rec.MarkdownFile)); !os.IsNotExist(err) {
+					t.Errorf("Markdown file of %s kept: %v", rec.ID, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRecordSize(t *testing.T) {
+	store := pruneStore(t)
+	records, err := store.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+
+	// A record uses its index line and its Markdown file
+	var lines int64
+	for _, rec := range records {
+		info, err := os.Stat(filepath.Join(store.Dir(), rec.MarkdownFile))
+		if err != nil {
+			t.Fatalf("Markdown file of %s: %v", rec.ID, err)
+		}
+		lines += store.Size(rec) - info.Size()
+	}
+	index, err := os.Stat(filepath.Join(store.Dir(), indexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines != index.Size() {
+		t.Errorf("index lines add up to %d bytes, the index file has %d", lines, index.Size())
+	}
+}
@@ -0,0 +1,213 @@
+package history
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"groq-cli-chat/internal/groq"
+	"groq-cli-chat/resources"
+)
+
+// snippetContext is the number of characters shown around a match
+const snippetContext = 40
+
+// Query selects history records. Empty fields match everything.
+type Query struct {
+	Text     string    // Searched in prompts and responses
+	Regex    bool      // Treat Text as a regular expression instead of a case-insensitive substring
+	Model    string    // Case-insensitive substring of the model name
+	Provider string    // Case-insensitive provider name
+	Since    time.Time // Inclusive
+	Until    time.Time // Exclusive
+}
+
+// Match is a record found by Search with a snippet around the first hit
+type Match struct {
+	Record     *Record
+	Role       string   // Role of the message containing the hit
+	Snippet    string   // Single-line excerpt around the hit
+	Highlights [][2]int // Byte ranges of the hits within Snippet
+}
+
+// matcher returns a function reporting all match ranges of the query text in s
+func (q Query) matcher() (func(s string) [][]int, error) {
+	if q.Regex {
+		re, err := regexp.Compile(q.Text)
+		if err != nil {
+			return nil, fmt.Errorf(resources.ErrInvalidRegex, err)
+		}
+		return func(s string) [][]int { return re.FindAllStringIndex(s, -1) }, nil
+	}
+
+	re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(q.Text))
+	return func(s string) [][]int { return re.FindAllStringIndex(s, -1) }, nil
+}
+
+// matchesFilters reports whether rec passes the model, provider and date filters
+func (q Query) matchesFilters(rec *Record) bool {
+	if q.Model != "" && !strings.Contains(strings.ToLower(rec.Model), strings.ToLower(q.Model)) {
+		return false
+	}
+	if q.Provider != "" && !strings.EqualFold(rec.Provider, q.Provider) {
+		return false
+	}
+	if !q.Since.IsZero() && rec.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !rec.Time.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// Find returns the records matching the query, oldest first
+func (s *Store) Find(q Query) ([]*Record, error) {
+	matches, err := s.Search(q)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*Record, len(matches))
+	for i, match := range matches {
+		records[i] = match.Record
+	}
+	return records, nil
+}
+
+// Search returns the records matching the query with a snippet of the first hit,
+// oldest first. Without query text every record passing the filters matches.
+func (s *Store) Search(q Query) ([]Match, error) {
+	find, err := q.matcher()
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Match
+	for _, rec := range records {
+		if !q.matchesFilters(rec) {
+			continue
+		}
+		if q.Text == "" {
+			matches = append(matches, Match{Record: rec, Role: groq.RoleUser, Snippet: snippet(rec.Prompt())})
+			continue
+		}
+
+		for _, msg := range rec.Messages {
+			if msg.Role == groq.RoleSystem {
+				continue
+			}
+			if hits := find(msg.Content); len(hits) > 0 {
+				match := Match{Record: rec, Role: msg.Role}
+				match.Snippet, match.Highlights = snippetAround(msg.Content, hits)
+				matches = append(matches, match)
+				break
+			}
+		}
+	}
+
+	return matches, nil
+}
+
+// snippetAround cuts the text around the first hit and maps the hits into it
+func snippetAround(text string, hits [][]int) (string, [][2]int) {
+	start := max(hits[0][0]-snippetContext, 0)
+	end := min(hits[0][1]+snippetContext, len(text))
+	// Avoid cutting multi-byte characters
+	for start > 0 && !isRuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !isRuneStart(text[end]) {
+		end++
+	}
+
+	prefix, suffix := "", ""
+	if start > 0 {
+		prefix = "…"
+	}
+	if end < len(text) {
+		suffix = "…"
+	}
+
+	// Newlines become spaces, which keeps byte offsets intact
+	excerpt := strings.NewReplacer("\n", " ", "\r", " ", "\t", " ").Replace(text[start:end])
+
+	var highlights [][2]int
+	for _, hit := range hits {
+		if hit[0] >= start && hit[1] <= end {
+			highlights = append(highlights, [2]int{hit[0] - start + len(prefix), hit[1] - start + len(prefix)})
+		}
+	}
+
+	return prefix + excerpt + suffix, highlights
+}
+
+// snippet returns the beginning of text as a single line
+func snippet(text string) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) <= 2*snippetContext {
+		return string(runes)
+	}
+	return string(runes[:2*snippetContext]) + "…"
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// ParseTime parses a date filter: YYYY-MM-DD, "YYYY-MM-DD HH:MM", or an age
+// relative to now such as 30d or 12h. With endOfDay a plain date selects the
+// end of that day, so an --until date is inclusive.
+func ParseTime(value string, endOfDay bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if age, err := ParseAge(value); err == nil {
+		return time.Now().Add(-age), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf(resources.ErrInvalidDate, value)
+}
+
+// ParseAge parses a duration that may use days, e.g. 30d, 36h or 1d12h
+func ParseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	days, rest, found := strings.Cut(value, "d")
+	if !found {
+		age, err := time.ParseDuration(value)
+		if err != nil || age < 0 {
+			return 0, fmt.Errorf(resources.ErrInvalidAge, value)
+		}
+		return age, nil
+	}
+
+	n, err := strconv.Atoi(days)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf(resources.ErrInvalidAge, value)
+	}
+	age := time.Duration(n) * 24 * time.Hour
+	if rest != "" {
+		extra, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, fmt.Errorf(resources.ErrInvalidAge, value)
+		}
+		age += extra
+	}
+	return age, nil
+}
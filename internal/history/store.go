@@ -0,0 +1,311 @@
+package history
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"groq-cli-chat/resources"
+)
+
+const (
+	indexFile       = "index.jsonl"
+	timestampLayout = "20060102_150405"
+)
+
+// Options configures a Store
+type Options struct {
+	Dir      string // Defaults to ~/.groq-chat/history
+	Markdown bool   // Also render every record as a Markdown file, unless encrypted
+	Encrypt  bool   // Encrypt records, existing plaintext ones are encrypted on open
+
+	// Passphrase is called for the passphrase when the store is encrypted.
+	// confirm is set when a new key is created, so a typo can be caught.
+	Passphrase func(confirm bool) (string, error)
+}
+
+// Store keeps chat history as one JSON record per line in index.jsonl,
+// optionally with a Markdown rendering of every record next to it.
+// In an encrypted store every line is sealed with AES-GCM instead.
+type Store struct {
+	dir      string
+	markdown bool
+	aead     cipher.AEAD // Nil for a plaintext store
+}
+
+// DefaultDir returns the default history directory
+func DefaultDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf(resources.ErrHomeDir, err)
+	}
+	return filepath.Join(homeDir, ".groq-chat", "history"), nil
+}
+
+// Open opens the store, importing Markdown files written by older versions
+// the first time it is used.
+func Open(opts Options) (*Store, error) {
+	if opts.Dir == "" {
+		dir, err := DefaultDir()
+		if err != nil {
+			return nil, err
+		}
+		opts.Dir = dir
+	}
+
+	s := &Store{dir: opts.Dir, markdown: opts.Markdown}
+	if err := s.recoverRekey(); err != nil {
+		return nil, err
+	}
+	if err := s.migrate(); err != nil {
+		return nil, err
+	}
+
+	// Once encrypted, the history stays encrypted even if the config says otherwise
+	if opts.Encrypt || s.isEncrypted() {
+		if opts.Passphrase == nil {
+			return nil, fmt.Errorf(resources.ErrHistoryLocked)
+		}
+		passphrase, err := opts.Passphrase(!s.isEncrypted())
+		if err != nil {
+			return nil, err
+		}
+		if err := s.unlock(passphrase); err != nil {
+			return nil, err
+		}
+		// Plaintext Markdown copies would defeat the encryption
+		s.markdown = false
+		if err := s.encryptAll(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Encrypted reports whether records are encrypted
+func (s *Store) Encrypted() bool {
+	return s.aead != nil
+}
+
+// Dir returns the history directory
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Save assigns an ID to rec, appends it to the index and renders it as Markdown if enabled
+func (s *Store) Save(rec *Record) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf(resources.ErrCreateHistoryDir, err)
+	}
+
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	if rec.ID == "" {
+		rec.ID = newID(rec.Time)
+	}
+
+	if s.markdown {
+		rec.MarkdownFile = "chat_" + rec.ID + ".md"
+		if err := os.WriteFile(filepath.Join(s.dir, rec.MarkdownFile), []byte(RenderMarkdown(rec)), 0600); err != nil {
+			return err
+		}
+	}
+
+	return s.append(rec)
+}
+
+// Update replaces a saved record, e.g. after a conversation turn was appended to it
+func (s *Store) Update(rec *Record) error {
+	records, err := s.List()
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for i := range records {
+		if records[i].ID == rec.ID {
+			records[i] = rec
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf(resources.ErrHistoryNotFound, rec.ID)
+	}
+
+	rec.Updated = time.Now()
+	if rec.MarkdownFile != "" {
+		if err := os.WriteFile(filepath.Join(s.dir, rec.MarkdownFile), []byte(RenderMarkdown(rec)), 0600); err != nil {
+			return err
+		}
+	}
+
+	return s.rewrite(records)
+}
+
+// List returns all records, oldest first
+func (s *Store) List() ([]*Record, error) {
+	records, _, err := s.load()
+	return records, err
+}
+
+// load reads all records, oldest first, and reports whether any of them
+// is stored in plaintext
+func (s *Store) load() ([]*Record, bool, error) {
+	file, err := os.Open(filepath.Join(s.dir, indexFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf(resources.ErrReadHistoryDir, err)
+	}
+	defer file.Close()
+
+	var records []*Record
+	plaintext := false
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if strings.HasPrefix(string(line), encryptedPrefix) {
+			if s.aead == nil {
+				return nil, false, fmt.Errorf(resources.ErrHistoryLocked)
+			}
+			if line, err = unseal(s.aead, string(line)); err != nil {
+				return nil, false, err
+			}
+		} else {
+			plaintext = true
+		}
+
+		rec := &Record{}
+		if err := json.Unmarshal(line, rec); err != nil {
+			return nil, false, fmt.Errorf(resources.ErrDecodeHistory, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, false, fmt.Errorf(resources.ErrReadHistoryDir, err)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+	return records, plaintext, nil
+}
+
+// encode returns the index line for a record, encrypted if the store is
+func (s *Store) encode(rec *Record) ([]byte, error) {
+	line, err := json.Marshal(rec)
+	if err != nil || s.aead == nil {
+		return line, err
+	}
+	sealed, err := seal(s.aead, line)
+	return []byte(sealed), err
+}
+
+// Get returns the record with the given ID
+func (s *Store) Get(id string) (*Record, error) {
+	records, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, rec := range records {
+		if rec.ID == id {
+			return rec, nil
+		}
+	}
+	return nil, fmt.Errorf(resources.ErrHistoryNotFound, id)
+}
+
+// Delete removes the records with the given IDs and their Markdown files
+func (s *Store) Delete(ids ...string) error {
+	remove := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+
+	records, err := s.List()
+	if err != nil {
+		return err
+	}
+
+	var kept []*Record
+	for _, rec := range records {
+		if !remove[rec.ID] {
+			kept = append(kept, rec)
+			continue
+		}
+		if rec.MarkdownFile != "" {
+			if err := os.Remove(filepath.Join(s.dir, rec.MarkdownFile)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	return s.rewrite(kept)
+}
+
+// append adds a single record to the end of the index
+func (s *Store) append(rec *Record) error {
+	line, err := s.encode(rec)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filepath.Join(s.dir, indexFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// rewrite replaces the index with records, atomically via a temporary file
+func (s *Store) rewrite(records []*Record) error {
+	return s.writeIndex(records, indexFile)
+}
+
+// writeIndex writes records to the named file in the store directory, atomically replacing it
+func (s *Store) writeIndex(records []*Record, name string) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf(resources.ErrCreateHistoryDir, err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, indexFile+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	for _, rec := range records {
+		line, err := s.encode(rec)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		writer.Write(append(line, '\n'))
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, name))
+}
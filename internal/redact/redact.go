@@ -0,0 +1,167 @@
+// Package redact finds secrets and personal data in text and masks them.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"groq-cli-chat/internal/groq"
+	"groq-cli-chat/resources"
+)
+
+// Modes
+const (
+	ModeOff     = "off"     // No scanning
+	ModeWarn    = "warn"    // Warn about findings, send and save the text as-is
+	ModeMask    = "mask"    // Mask findings before sending, so they are never sent or saved
+	ModeHistory = "history" // Send as-is, mask findings in the saved history and sessions
+)
+
+// Settings configure redaction in config.yaml
+type Settings struct {
+	Mode     string    `mapstructure:"mode"`     // Defaults to warn
+	Disable  []string  `mapstructure:"disable"`  // Built-in detectors to turn off, by name
+	Patterns []Pattern `mapstructure:"patterns"` // User-defined detectors
+}
+
+// Pattern is a user-defined detector
+type Pattern struct {
+	Name  string `mapstructure:"name"`
+	Regex string `mapstructure:"regex"`
+}
+
+// detector finds one kind of secret
+type detector struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// builtin are the detectors available without configuration. More specific
+// patterns come first, e.g. Anthropic keys before other sk- keys.
+var builtin = []detector{
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{"groq-api-key", regexp.MustCompile(`\bgsk_[A-Za-z0-9]{20,}`)},
+	{"anthropic-api-key", regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`)},
+	{"openai-api-key", regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9_-]{20,}`)},
+	{"github-token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}`)},
+	{"aws-access-key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"aws-secret-key", regexp.MustCompile(`(?i)aws_secret_access_key\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)},
+	{"email", regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)},
+}
+
+// BuiltinNames returns the names of the built-in detectors
+func BuiltinNames() []string {
+	names := make([]string, len(builtin))
+	for i, d := range builtin {
+		names[i] = d.name
+	}
+	return names
+}
+
+// Finding counts the matches of one detector
+type Finding struct {
+	Name  string
+	Count int
+}
+
+// Redactor scans and masks text. A nil Redactor does nothing.
+type Redactor struct {
+	mode      string
+	detectors []detector
+}
+
+// New builds a redactor from the settings
+func New(s Settings) (*Redactor, error) {
+	mode := strings.ToLower(s.Mode)
+	switch mode {
+	case "":
+		mode = ModeWarn
+	case ModeOff, ModeWarn, ModeMask, ModeHistory:
+	default:
+		return nil, fmt.Errorf(resources.ErrRedactionMode, s.Mode)
+	}
+
+	for _, name := range s.Disable {
+		if !slices.Contains(BuiltinNames(), name) {
+			return nil, fmt.Errorf(resources.ErrUnknownDetector, name, strings.Join(BuiltinNames(), ", "))
+		}
+	}
+
+	r := &Redactor{mode: mode}
+	for _, d := range builtin {
+		if !slices.Contains(s.Disable, d.name) {
+			r.detectors = append(r.detectors, d)
+		}
+	}
+	for _, p := range s.Patterns {
+		re, err := regexp.Compile(p.Regex)
+		if err != nil {
+			return nil, fmt.Errorf(resources.ErrRedactionPattern, p.Name, err)
+		}
+		name := p.Name
+		if name == "" {
+			name = "custom"
+		}
+		r.detectors = append(r.detectors, detector{name, re})
+	}
+	return r, nil
+}
+
+// Mode returns the redaction mode
+func (r *Redactor) Mode() string {
+	if r == nil {
+		return ModeOff
+	}
+	return r.mode
+}
+
+// Mask replaces every finding in text with [REDACTED:<name>]
+func (r *Redactor) Mask(text string) (string, []Finding) {
+	if r.Mode() == ModeOff {
+		return text, nil
+	}
+
+	var findings []Finding
+	for _, d := range r.detectors {
+		count := 0
+		text = d.re.ReplaceAllStringFunc(text, func(string) string {
+			count++
+			return "[REDACTED:" + d.name + "]"
+		})
+		if count > 0 {
+			findings = append(findings, Finding{d.name, count})
+		}
+	}
+	return text, findings
+}
+
+// Scan reports the findings in text without changing it
+func (r *Redactor) Scan(text string) []Finding {
+	_, findings := r.Mask(text)
+	return findings
+}
+
+// MaskMessages returns a copy of messages with every finding masked
+func (r *Redactor) MaskMessages(messages []groq.Message) []groq.Message {
+	if r.Mode() == ModeOff {
+		return messages
+	}
+	masked := make([]groq.Message, len(messages))
+	for i, msg := range messages {
+		msg.Content, _ = r.Mask(msg.Content)
+		masked[i] = msg
+	}
+	return masked
+}
+
+// Summary formats findings for display, e.g. "2 email, 1 groq-api-key"
+func Summary(findings []Finding) string {
+	parts := make([]string, len(findings))
+	for i, f := range findings {
+		parts[i] = fmt.Sprintf("%d %s", f.Count, f.Name)
+	}
+	return strings.Join(parts, ", ")
+}
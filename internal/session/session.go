@@ -0,0 +1,363 @@
+// Package session keeps named chat sessions, the live state of a REPL
+// conversation, as one JSON file per session.
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"groq-cli-chat/internal/groq"
+	"groq-cli-chat/resources"
+)
+
+const (
+	fileExt    = ".json"
+	activeFile = ".active" // Name of the session in use, removed on a clean exit
+)
+
+// validName allows names that are safe to use as file names
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// Session is the state of a chat that can be restored later
+type Session struct {
+	Name           string          `json:"name"`
+	Created        time.Time       `json:"created"`
+	Updated        time.Time       `json:"updated"`
+	Model          string          `json:"model"`
+	Persona        string          `json:"persona,omitempty"`
+	SystemPrompt   string          `json:"system_prompt,omitempty"`
+	Conversational bool            `json:"conversational"`
+	Parameters     groq.Parameters `json:"parameters"`         // Overrides set with /set
+	Messages       []groq.Message  `json:"messages,omitempty"` // Without the system prompt
+	HistoryID      string          `json:"history_id,omitempty"`
+}
+
+// Turns returns the number of prompts in the session
+func (s *Session) Turns() int {
+	turns := 0
+	for _, msg := range s.Messages {
+		if msg.Role == groq.RoleUser {
+			turns++
+		}
+	}
+	return turns
+}
+
+// NewName returns a name for an unnamed session based on the current time
+func NewName() string {
+	return "session_" + time.Now().Format("20060102_150405")
+}
+
+// ValidateName reports whether name can be used for a session
+func ValidateName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf(resources.ErrInvalidSessionName, name)
+	}
+	return nil
+}
+
+// Cipher encrypts session files at rest, e.g. with the key of an encrypted history
+type Cipher interface {
+	Seal(data []byte) ([]byte, error)
+	Unseal(data []byte) ([]byte, error)
+}
+
+// Store keeps sessions as <name>.json files in a directory
+type Store struct {
+	dir    string
+	cipher Cipher // Nil for plaintext sessions
+}
+
+// DefaultDir returns the default sessions directory
+func DefaultDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf(resources.ErrHomeDir, err)
+	}
+	return filepath.Join(homeDir, ".groq-chat", "sessions"), nil
+}
+
+// Open returns the store in dir, or in the default directory when dir is empty
+func Open(dir string) (*Store, error) {
+	if dir == "" {
+		var err error
+		if dir, err = DefaultDir(); err != nil {
+			return nil, err
+		}
+	}
+	return &Store{dir: dir}, nil
+}
+
+// SetCipher encrypts sessions from now on, including plaintext ones saved before
+func (st *Store) SetCipher(c Cipher) error {
+	st.cipher = c
+	names, err := st.names()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		data, err := os.ReadFile(st.path(name))
+		if err != nil || isSealed(data) {
+			continue
+		}
+		if err := st.write(name, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reseal re-encrypts every session when the cipher changes its key: the
+// sessions are read before rekey is called and written again once it succeeded
+func (st *Store) Reseal(rekey func() error) error {
+	names, err := st.names()
+	if err != nil {
+		return err
+	}
+	plain := make(map[string][]byte, len(names))
+	for _, name := range names {
+		data, err := st.read(name)
+		if err != nil {
+			// Sessions that cannot be read now could not be read later either
+			continue
+		}
+		plain[name] = data
+	}
+
+	if err := rekey(); err != nil {
+		return err
+	}
+	for name, data := range plain {
+		if err := st.write(name, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (st *Store) path(name string) string {
+	return filepath.Join(st.dir, name+fileExt)
+}
+
+// Exists reports whether a session with the given name is saved
+func (st *Store) Exists(name string) bool {
+	_, err := os.Stat(st.path(name))
+	return err == nil
+}
+
+// Save writes the session, replacing a previous version atomically
+func (st *Store) Save(s *Session) error {
+	if err := ValidateName(s.Name); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(st.dir, 0755); err != nil {
+		return fmt.Errorf(resources.ErrSaveSession, err)
+	}
+
+	now := time.Now()
+	if s.Created.IsZero() {
+		s.Created = now
+	}
+	s.Updated = now
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf(resources.ErrSaveSession, err)
+	}
+	return st.write(s.Name, data)
+}
+
+// Load reads the named session
+func (st *Store) Load(name string) (*Session, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+	data, err := st.read(name)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Session{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf(resources.ErrReadSession, err)
+	}
+	s.Name = name
+	return s, nil
+}
+
+// List returns all sessions, most recently updated first
+func (st *Store) List() ([]*Session, error) {
+	names, err := st.names()
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []*Session
+	for _, name := range names {
+		s, err := st.Load(name)
+		if err != nil {
+			// Skip damaged files rather than hiding every other session
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Updated.After(sessions[j].Updated)
+	})
+	return sessions, nil
+}
+
+// names returns the names of all saved sessions
+func (st *Store) names() ([]string, error) {
+	entries, err := os.ReadDir(st.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf(resources.ErrReadSession, err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), fileExt)
+		if entry.IsDir() || !ok || ValidateName(name) != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// isSealed reports whether a session file is encrypted; plaintext ones are JSON objects
+func isSealed(data []byte) bool {
+	return len(data) > 0 && data[0] != '{'
+}
+
+// read returns the JSON of the named session, decrypted if it is sealed
+func (st *Store) read(name string) ([]byte, error) {
+	data, err := os.ReadFile(st.path(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf(resources.ErrSessionNotFound, name)
+		}
+		return nil, fmt.Errorf(resources.ErrReadSession, err)
+	}
+	if !isSealed(data) {
+		return data, nil
+	}
+	if st.cipher == nil {
+		return nil, fmt.Errorf(resources.ErrSessionLocked, name)
+	}
+	if data, err = st.cipher.Unseal(data); err != nil {
+		return nil, fmt.Errorf(resources.ErrReadSession, err)
+	}
+	return data, nil
+}
+
+// write replaces the named session file with data, sealed if a cipher is set,
+// atomically via a temporary file
+func (st *Store) write(name string, data []byte) error {
+	if st.cipher != nil {
+		var err error
+		if data, err = st.cipher.Seal(data); err != nil {
+			return fmt.Errorf(resources.ErrSaveSession, err)
+		}
+	}
+
+	tmp, err := os.CreateTemp(st.dir, name+fileExt+".*")
+	if err != nil {
+		return fmt.Errorf(resources.ErrSaveSession, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf(resources.ErrSaveSession, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf(resources.ErrSaveSession, err)
+	}
+	if err := os.Rename(tmp.Name(), st.path(name)); err != nil {
+		return fmt.Errorf(resources.ErrSaveSession, err)
+	}
+	return nil
+}
+
+// Rename gives a saved session a new name
+func (st *Store) Rename(oldName, newName string) error {
+	s, err := st.Load(oldName)
+	if err != nil {
+		return err
+	}
+	if err := ValidateName(newName); err != nil {
+		return err
+	}
+	if st.Exists(newName) {
+		return fmt.Errorf(resources.ErrSessionExists, newName)
+	}
+
+	s.Name = newName
+	if err := st.Save(s); err != nil {
+		return err
+	}
+	if err := os.Remove(st.path(oldName)); err != nil {
+		return fmt.Errorf(resources.ErrDeleteSession, err)
+	}
+	if active, _ := st.Active(); active == oldName {
+		return st.SetActive(newName)
+	}
+	return nil
+}
+
+// Delete removes the named session
+func (st *Store) Delete(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if err := os.Remove(st.path(name)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf(resources.ErrSessionNotFound, name)
+		}
+		return fmt.Errorf(resources.ErrDeleteSession, err)
+	}
+	return nil
+}
+
+// SetActive records the session in use. The record survives a crash or a
+// killed terminal, so the next start can offer to restore the session.
+func (st *Store) SetActive(name string) error {
+	if err := os.MkdirAll(st.dir, 0755); err != nil {
+		return fmt.Errorf(resources.ErrSaveSession, err)
+	}
+	if err := os.WriteFile(filepath.Join(st.dir, activeFile), []byte(name+"\n"), 0644); err != nil {
+		return fmt.Errorf(resources.ErrSaveSession, err)
+	}
+	return nil
+}
+
+// Active returns the session that was in use when the last chat ended
+// without a clean exit, or "" if there is none
+func (st *Store) Active() (string, error) {
+	data, err := os.ReadFile(filepath.Join(st.dir, activeFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf(resources.ErrReadSession, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ClearActive marks a clean exit
+func (st *Store) ClearActive() error {
+	if err := os.Remove(filepath.Join(st.dir, activeFile)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf(resources.ErrDeleteSession, err)
+	}
+	return nil
+}
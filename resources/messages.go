@@ -1,15 +1,22 @@
 package resources
 
 const (
-	MenuOptions = "[i]nfo | select [m]odel | [u]pdate models | [h]istory | change [c]onfig | [q]uit"
-	WelcomeMessage = `🍎 One-shot Groq CLI chat
-` + MenuOptions + `
+	WelcomeMessage = "🍎 One-shot Groq CLI chat"
 
-`
+	// Menu labels of the commands with a shortcut, the others are shown as /name
+	MenuSeparator = " | "
+	MenuInfo      = "[i]nfo"
+	MenuModel     = "select [m]odel"
+	MenuUpdate    = "[u]pdate models"
+	MenuHistory   = "[h]istory"
+	MenuConfig    = "change [c]onfig"
+	MenuQuit      = "[q]uit"
 
-	Prompt           = "[%s] > "
-	InfoModel        = "Current model: %s\n"
-	InfoModelDetails = `────────┤ Model Information ├─────────
+	Prompt             = "%s[%s] > "
+	ConversationPrompt = "%s[%s | turn %d] > "
+	IncognitoMarker    = "🕶 incognito "
+	InfoModel          = "Current model: %s\n"
+	InfoModelDetails   = `────────┤ Model Information ├─────────
 - ID: %s
 - Owned By: %s
 - Active: %v
@@ -23,23 +30,155 @@ Select model (0-%d): `
 	GoodbyeMessage     = "Goodbye!"
 	InfoModelUnchanged = "Invalid selection, model unchanged: %s\n"
 
-	HistoryFormat = `# Chat History (%s)
+	InfoModeOneShot      = "One-shot mode: each prompt is sent without previous context.\n"
+	InfoModeConversation = "Conversation mode: follow-up prompts keep the context of previous messages.\n"
+	InfoNewConversation  = "Started a new conversation.\n"
+
+	InfoRequestCancelled = "Request cancelled.\n"
+	InfoInterruptHint    = "\n(Press Ctrl-C again or type q to quit)\n"
+	InfoRetrying         = "API returned status %d, retrying in %.1fs (attempt %d/%d)...\n"
+
+	SelectPersonaHeader = `────────┤ Available personas ├─────────`
+	SelectPersonaPrompt = `─────────────────────────────────────
+Select persona (0-%d): `
+	DefaultPersonaName   = "default"
+	NoSystemPrompt       = "(none)"
+	InfoPersonaSelected  = "Persona: %s\n"
+	InfoPersonaUnchanged = "Persona unchanged: %s\n"
+	HistoryPersonaName   = "(from history)"
+
+	InfoResumed      = "Resumed %s with %s (%d turn(s)), continuing in conversation mode\n"
+	InfoLastResponse = "Last response: %s\n"
+	WarnResumeModel  = "Warning: model %s is not in the models list of this config\n"
+
+	WarnHistoryNotSaved = "Warning: the chat history is not open, this response was not saved (use /incognito to chat without history)\n"
+
+	// Redaction
+	WarnSecrets         = "Warning: the prompt may contain secrets (%s), sending as-is\n"
+	InfoRedactedPrompt  = "Redacted before sending: %s\n"
+	InfoRedactedHistory = "Will be redacted in the saved history: %s\n"
+
+	// Sessions
+	UsageSession          = "Usage: /session [list | new [name] | save [name] | load <name> | rename [old] <new> | delete <name>]"
+	SessionListHeader     = "────────┤ Sessions ├─────────"
+	SessionListFormat     = "%s %s | %s | %s | %d turn(s)\n"
+	InfoNoSessions        = "No saved sessions."
+	InfoSessionNew        = "Started session %s\n"
+	InfoSessionSaved      = "Session saved as %s\n"
+	InfoSessionLoaded     = "Loaded session %s with %s (%d turn(s))\n"
+	InfoSessionRenamed    = "Renamed session %s to %s\n"
+	InfoSessionDeleted    = "Deleted session %s\n"
+	ConfirmDeleteSession  = "Delete session %s? (y/n): "
+	ConfirmRecoverSession = "The last chat did not exit cleanly. Resume session %s (%s, %d turn(s), %s)? (y/n): "
+
+	DefaultParameters = "(provider defaults)"
+	InfoParameters    = "Parameters: %s\n"
+	UsageSet          = "Usage: /set <name> <value|default>, e.g. /set temperature 0.2"
+	UsageMode         = "Usage: /mode [oneshot|conversation]"
+	UsageIncognito    = "Usage: /incognito [on|off]"
+	InfoIncognitoOn   = "Incognito mode on: history and sessions are not saved\n"
+	InfoIncognitoOff  = "Incognito mode off: history and sessions are saved again\n"
+	UsageSearch       = "Usage: /search [--regex] [--model name] [--provider name] [--since date] [--until date] <text>"
+
+	// History viewer
+	HistoryListFormat   = "%d - %s | %s | %s\n"
+	SelectHistoryPrompt = `─────────────────────────────────────
+Select entry to open (0-%d, Enter to go back): `
+	HistoryEntryFormat = `────────┤ %s ├─────────
+Model: %s
+
+%s─────────────────────────────────────
+`
+	HistoryEntryMessage  = "%s:\n%s\n"
+	HistoryActions       = "[c]ontinue conversation | [r]e-send | re-send to another [m]odel | [d]elete | [s]ave response to file | [b]ack: "
+	ConfirmDeleteHistory = "Delete %s? (y/n): "
+	InfoHistoryDeleted   = "Deleted %s\n"
+	SaveResponsePrompt   = "File name [%s]: "
+	InfoResponseSaved    = "Response saved to %s\n"
+
+	// History search
+	SearchResultsHeader      = "────────┤ %d result(s) ├─────────\n"
+	SearchResultFormat       = "%d - %s | %s | %s\n    %s: %s\n"
+	InfoNoSearchResults      = "No matching chats found."
+	SelectSearchResultPrompt = `─────────────────────────────────────
+Select result to open (0-%d, Enter to go back): `
+
+	// Command help
+	HelpHeader = `────────┤ Commands ├─────────`
+	HelpFooter = `─────────────────────────────────────
+Start a line with \ to send it as-is, e.g. \/help or \q`
+	HelpShortcuts = "Shortcuts without the slash: %s"
+	HelpInfo      = "Show current model info"
+	HelpModel     = "Select a model, or switch to the named one"
+	HelpUpdate    = "Update the models list from the provider"
+	HelpHistory   = "Browse saved chats: view, re-send, delete or save a response"
+	HelpSearch    = "Search saved chats and open a result"
+	HelpResume    = "Continue a saved conversation, the most recent one by default"
+	HelpIncognito = "Toggle incognito mode: don't save history or sessions"
+	HelpSession   = "Manage named sessions: new, save, load, list, rename, delete"
+	HelpConfig    = "Switch to another config file"
+	HelpPersona   = "Select a persona, or switch to the named one (case-insensitive)"
+	HelpNew       = "Start a new conversation"
+	HelpMode      = "Toggle or set one-shot/conversation mode"
+	HelpSet       = "Show or set a generation parameter"
+	HelpHelp      = "Show this help"
+	HelpQuit      = "Quit"
+
+	HistoryMessageFormat = "**%s**: %s\n" // A message of a Markdown history file
+	HistoryFormat        = `# Chat History (%s)
 **Model**: %s
-**User**: %s
-**Response**: %s
-**Stats**:
+**Parameters**: %s
+**System**: %s
+%s**Stats**:
+- Prompt Tokens: %d
+- Completion Tokens: %d
 - Total Tokens: %d
+- Queue Time: %.2f seconds
+- Prompt Time: %.2f seconds
 - Completion Time: %.2f seconds
-- Tokens per Second: %.2f
+- Total Time: %.2f seconds
+- Output Tokens per Second: %.2f
+- Request ID: %s
 `
 
-	StatsFormat = `───┤ Stats: %d tokens | %.2f sec | %.2f tok/sec ├───
+	// History export
+	ExportMarkdownHeader = `# Chat History
+
+Exported %s · entries: %d
+
+`
+	ExportMarkdownEntry = `---
+
+## %s · %s
+
+*%s · %s*
+
+`
+	ExportMarkdownMessage = `**%s**:
+
+%s
+
+`
+	ExportMarkdownStats  = "*%d tokens (%d prompt + %d completion) · %.2f sec · %.2f tok/sec*\n\n"
+	InfoPruned           = "Removed %d old history entries (%s) per history_retention\n"
+	PruneListHeader      = "────────┤ %s ├─────────\n"
+	PruneWouldRemove     = "Would remove %d entries (%s)"
+	PruneRemoved         = "Removed %d entries (%s)"
+	InfoHistoryEncrypted = "Encrypted %d history entries, their Markdown copies were removed\n"
+	PassphrasePrompt     = "History passphrase: "
+	NewPassphrasePrompt  = "New history passphrase: "
+	RepeatPassphrase     = "Repeat new passphrase: "
+	InfoRekeyed          = "History re-encrypted with the new passphrase (entries: %d)\n"
+	InfoExported         = "Exported to %s (entries: %d)\n"
+
+	StatsFormat = `───┤ Stats: %d tokens (%d prompt + %d completion) | queue %.2f sec | %.2f sec | %.2f tok/sec ├───
 
 `
 
 	// Error messages
 	ErrLoadConfig          = "failed to load config: %v\n"
 	ErrExecuteCmd          = "failed to execute command: %v\n"
+	ErrHistoryCmd          = "history: %v\n"
 	ErrHomeDir             = "failed to get home directory: %v"
 	ErrConfigDir           = "failed to create config directory: %v"
 	ErrCreateConfig        = "failed to create default config: %v"
@@ -49,12 +188,21 @@ Select model (0-%d): `
 	ErrWriteConfig         = "failed to write config: %v"
 	ErrCreateClient        = "failed to create Groq client: %v"
 	ErrListModels          = "failed to list models: %v"
-	ErrInvalidClientParams = "invalid client parameters: baseURL or apiKey is empty"
+	ErrInvalidClientParams = "invalid client parameters: baseURL is empty"
 	ErrCreateRequest       = "failed to create request: %v"
 	ErrHTTP                = "HTTP request failed: %v"
+	ErrIdleTimeout         = "no data received from the API for %s"
 	ErrAPI                 = "API error (status %d): %s"
+	ErrAPICode             = "API error (status %d, %s): %s"
 	ErrDecodeResponse      = "failed to decode response: %v"
+	ErrReadResponse        = "failed to read response body: %v"
+	ErrReadStream          = "failed to read response stream: %v"
+	ErrEmptyResponse       = "received empty response from API"
 	ErrEncodePayload       = "failed to encode payload: %v"
+	ErrStreamEvent         = "API error in response stream (%s): %s"
+	ErrUnknownProviderType = "unknown provider_type %q, expected one of: %s"
+	ErrUnknownAuthType     = "unknown auth type %q, expected one of: %s"
+	ErrAuthNoName          = "auth type %q requires a name"
 	ErrChat                = "chat request failed: %v"
 	ErrSelectModel         = "failed to select model: %v"
 	ErrReadInput           = "failed to read input"
@@ -67,9 +215,74 @@ Select model (0-%d): `
 	ErrInvalidConfig       = "invalid configuration: %v"
 	ErrInvalidDefaultModel = "invalid default model: %s"
 	ErrGetModel            = "failed to retrieve model information: %v"
+	ErrUnknownPersona      = "unknown persona: %s"
+	ErrUnknownModel        = "unknown model: %s"
+	ErrReadHistoryFile     = "failed to read history file: %v"
+	ErrParseHistoryFile    = "failed to parse history file: %s"
+	ErrDecodeHistory       = "failed to decode history record: %v"
+	ErrHistoryNotFound     = "history entry not found: %s"
+	ErrOpenHistory         = "failed to open chat history: %v"
+	ErrHistoryUnavailable  = "history store is not available"
+	ErrNoHistory           = "no chat history found"
+	ErrInvalidSessionName  = "invalid session name %q, use up to 64 letters, digits, dots, dashes and underscores"
+	ErrSessionNotFound     = "session not found: %s"
+	ErrSessionExists       = "session already exists: %s"
+	ErrSaveSession         = "failed to save session: %v"
+	ErrReadSession         = "failed to read session: %v"
+	ErrDeleteSession       = "failed to delete session: %v"
+	ErrSessionsUnavailable = "sessions are not available"
+	ErrSessionLocked       = "session %s is encrypted, unlock the chat history to open it"
+	ErrSessionsLocked      = "sessions are not available while the encrypted chat history is locked"
+	ErrIncognito           = "sessions are not saved in incognito mode, turn it off with /incognito"
+	ErrNoSession           = "no current session, save it with /session save <name> first"
+	ErrInvalidRegex        = "invalid regular expression: %v"
+	ErrInvalidDate         = "invalid date %q, expected YYYY-MM-DD, \"YYYY-MM-DD HH:MM\" or an age such as 30d"
+	ErrInvalidAge          = "invalid age %q, expected e.g. 30d, 12h or 1d12h"
+	ErrInvalidSize         = "invalid size %q, expected e.g. 500KB, 50MB or 1GB"
+	ErrHistoryLocked       = "the chat history is encrypted, enable history_encryption in the config to open it"
+	ErrWrongPassphrase     = "wrong history passphrase"
+	ErrEmptyPassphrase     = "the history passphrase must not be empty"
+	ErrPassphraseMismatch  = "the passphrases do not match"
+	ErrNoPassphrase        = "no history passphrase: set %s or run in a terminal"
+	ErrDecryptHistory      = "failed to decrypt history record, the index may be damaged"
+	ErrHistoryKeyFile      = "history key file: %v"
+	ErrHistoryNotEncrypted = "the chat history is not encrypted, set history_encryption.enabled in the config first"
+	ErrHistoryRetention    = "invalid history_retention setting: %v"
+	ErrNoPruneLimits       = "no limits given, use --older-than, --max-entries or --max-size, or set history_retention in the config"
+	ErrRedactionMode       = "invalid redaction mode %q, expected off, warn, mask or history"
+	ErrUnknownDetector     = "unknown redaction detector %q, expected one of: %s"
+	ErrRedactionPattern    = "invalid redaction pattern %q: %v"
+	ErrRedaction           = "invalid redaction settings: %v\n"
+	ErrUnknownExportFormat = "unknown export format %q, expected one of: %s"
+	ErrWriteExport         = "failed to write export: %v"
+	ErrDeleteHistory       = "failed to delete history file: %v"
+	ErrSaveResponse        = "failed to save response: %v"
+	ErrUnknownCommand      = "unknown command /%s, type /help for the list of commands"
+	ErrNoPrompt            = "no prompt given: pass it as arguments or pipe it via stdin"
+	ErrReadStdin           = "failed to read stdin: %v"
+	ErrUnknownParam        = "unknown parameter %q, expected one of: %s"
+	ErrInvalidParamValue   = "invalid value for %s: %q"
+
+	// Hints for API errors
+	HintFormat           = "Hint: %s"
+	HintAuth             = "check the API key in the %s environment variable"
+	HintModelUnavailable = "model not found or decommissioned, run /update to update the models list and /model to select another one"
+	HintContextLength    = "the prompt is too long for this model, start a /new conversation or select a model with a larger context window"
+	HintRateLimit        = "rate limit reached, wait a moment or select another model with /model"
+	HintRateLimitWait    = "rate limit reached, try again in %.0fs or select another model with /model"
+	InfoRequestID        = "Request ID: %s"
+
+	// Hints of the ask command, which has no REPL commands
+	HintAskModelUnavailable = "model not found or decommissioned, choose another one from the config with --model"
+	HintAskContextLength    = "the prompt is too long for this model, shorten it or choose a model with a larger context window with --model"
+	HintAskRateLimit        = "rate limit reached, wait a moment or choose another model with --model"
+	HintAskRateLimitWait    = "rate limit reached, try again in %.0fs or choose another model with --model"
 
 	// Info messages
 	InfoConfigCreated = "Config created at ~/.groq-chat/config.yaml. Please review and adjust models and default model."
 )
 
 const DefaultBaseURL = "https://api.groq.com/openai/v1"
+
+// DefaultOllamaURL is used when provider_type is ollama and base_url is not set
+const DefaultOllamaURL = "http://localhost:11434"